@@ -0,0 +1,118 @@
+package concgroup
+
+// KeyMode is the mode to lock a key in.
+type KeyMode int
+
+const (
+	// Exclusive locks a key so that no other function with the key runs at the same time. It is the mode of Go and GoMulti.
+	Exclusive KeyMode = iota
+	// Shared locks a key so that functions locking it in Shared mode run at the same time,
+	// while a function locking it in Exclusive mode does not run with any of them.
+	Shared
+	// IntentionShared locks a key as an intention to lock some of its descendants in Shared mode, like GoPath does
+	// for the ancestors of a path. It is compatible with the IntentionShared, IntentionExclusive and Shared modes.
+	IntentionShared
+	// IntentionExclusive locks a key as an intention to lock some of its descendants in Exclusive mode, like GoPath
+	// does for the ancestors of a path. It is compatible with the IntentionShared and IntentionExclusive modes,
+	// so that a function locking the key in Shared or Exclusive mode does not run with the descendants being written.
+	IntentionExclusive
+)
+
+// compatible reports whether functions locking a key in modes a and b run at the same time,
+// by the compatibility matrix of multiple granularity locking.
+// Exclusive is not compatible with itself, though the functions share a key up to its limit set by SetKeyLimit.
+func compatible(a, b KeyMode) bool {
+	switch a {
+	case Shared:
+		return b == Shared || b == IntentionShared
+	case IntentionShared:
+		return b != Exclusive
+	case IntentionExclusive:
+		return b == IntentionShared || b == IntentionExclusive
+	}
+	return false
+}
+
+// join returns the weakest mode covering both a and b.
+// Shared and IntentionExclusive join into Exclusive, as there is no mode of both.
+func join(a, b KeyMode) KeyMode {
+	switch {
+	case a == b:
+		return a
+	case a == IntentionShared:
+		return b
+	case b == IntentionShared:
+		return a
+	}
+	return Exclusive
+}
+
+// KeyAccess is a key and the mode to lock it in.
+type KeyAccess struct {
+	Key  string
+	Mode KeyMode
+}
+
+// GoShared calls the given function in a new goroutine like Go, locking key in Shared mode
+// so that it runs at the same time as the other functions locking key in Shared mode, like a reader of sync.RWMutex.
+func (g *Group) GoShared(key string, f func() error) {
+	g.GoMultiAccess([]KeyAccess{{Key: key, Mode: Shared}}, f)
+}
+
+// GoExclusive calls the given function in a new goroutine like Go, locking key in Exclusive mode
+// so that it runs alone among the functions with key, like a writer of sync.RWMutex. It is the same as Go.
+func (g *Group) GoExclusive(key string, f func() error) {
+	g.Go(key, f)
+}
+
+// GoMultiShared calls the given function in a new goroutine like GoMulti, locking all keys in Shared mode.
+func (g *Group) GoMultiShared(keys []string, f func() error) {
+	g.GoMultiAccess(accesses(keys, Shared), f)
+}
+
+// GoMultiExclusive calls the given function in a new goroutine like GoMulti, locking all keys in Exclusive mode. It is the same as GoMulti.
+func (g *Group) GoMultiExclusive(keys []string, f func() error) {
+	g.GoMulti(keys, f)
+}
+
+// GoMultiAccess calls the given function in a new goroutine like GoMulti, locking each key in its mode
+// (e.g. Shared "config" and Exclusive "cache/42") so that the function holds a consistent view of several resources
+// with minimal exclusivity. A key given more than once is locked in the weakest mode covering all of them,
+// such as Exclusive if any of them is Exclusive.
+// Waiting functions locking a key in Shared mode do not overtake an earlier one locking it in Exclusive mode.
+func (g *Group) GoMultiAccess(access []KeyAccess, f func() error) {
+	g.submit(accessSpec(access), Adapt(f))
+}
+
+// accessSpec returns the spec of the keys in access.
+func accessSpec(access []KeyAccess) spec {
+	modes := make(map[string]KeyMode, len(access))
+	for _, a := range access {
+		if m, ok := modes[a.Key]; ok {
+			modes[a.Key] = join(m, a.Mode)
+		} else {
+			modes[a.Key] = a.Mode
+		}
+	}
+	s := spec{keys: make([]string, 0, len(modes))}
+	for key, m := range modes {
+		s.keys = append(s.keys, key)
+		if m != Exclusive {
+			if s.modes == nil {
+				s.modes = map[string]KeyMode{}
+			}
+			s.modes[key] = m
+		}
+	}
+	s.keys = sortedKeys(s.keys)
+	return s
+}
+
+// accesses returns the accesses to keys in mode.
+func accesses(keys []string, mode KeyMode) []KeyAccess {
+	a := make([]KeyAccess, 0, len(keys))
+	for _, key := range keys {
+		a = append(a, KeyAccess{Key: key, Mode: mode})
+	}
+	return a
+}
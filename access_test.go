@@ -0,0 +1,194 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestGoMultiAccessShared(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var running, maxRunning int64
+	for i := 0; i < 5; i++ {
+		cg.GoMultiAccess([]concgroup.KeyAccess{{Key: "config", Mode: concgroup.Shared}}, func() error {
+			n := atomic.AddInt64(&running, 1)
+			defer atomic.AddInt64(&running, -1)
+			for {
+				m := atomic.LoadInt64(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning < 2 {
+		t.Errorf("got max %d running functions, want shared ones to run at the same time", maxRunning)
+	}
+}
+
+// rwChecker checks the invariant of a readers-writer lock per key.
+type rwChecker struct {
+	mu      sync.Mutex
+	readers map[string]int
+	writers map[string]int
+}
+
+func (c *rwChecker) enter(a []concgroup.KeyAccess) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ka := range a {
+		if c.writers[ka.Key] > 0 || (ka.Mode == concgroup.Exclusive && c.readers[ka.Key] > 0) {
+			return fmt.Errorf("violate %s access", ka.Key)
+		}
+	}
+	for _, ka := range a {
+		if ka.Mode == concgroup.Exclusive {
+			c.writers[ka.Key]++
+		} else {
+			c.readers[ka.Key]++
+		}
+	}
+	return nil
+}
+
+func (c *rwChecker) leave(a []concgroup.KeyAccess) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ka := range a {
+		if ka.Mode == concgroup.Exclusive {
+			c.writers[ka.Key]--
+		} else {
+			c.readers[ka.Key]--
+		}
+	}
+}
+
+func TestGoMultiAccessMixed(t *testing.T) {
+	t.Parallel()
+	for _, strategy := range []concgroup.Strategy{concgroup.SortedOrder, concgroup.AllOrNothing, concgroup.WaitDie, concgroup.WoundWait} {
+		cg := new(concgroup.Group)
+		cg.SetStrategy(strategy)
+		c := &rwChecker{readers: map[string]int{}, writers: map[string]int{}}
+		for i := 0; i < 100; i++ {
+			// Distinct keys in each access, as a key given twice is merged.
+			perm := rand.Perm(4) //nolint:gosec
+			var a []concgroup.KeyAccess
+			for _, k := range perm[:1+rand.Intn(3)] { //nolint:gosec
+				a = append(a, concgroup.KeyAccess{Key: fmt.Sprintf("key-%d", k), Mode: concgroup.KeyMode(rand.Intn(2))}) //nolint:gosec
+			}
+			cg.GoMultiAccess(a, func() error {
+				if err := c.enter(a); err != nil {
+					return err
+				}
+				defer c.leave(a)
+				time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond) //nolint:gosec
+				return nil
+			})
+		}
+		if err := cg.Wait(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestGoMultiAccessWriterNotStarved(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	shared := []concgroup.KeyAccess{{Key: "config", Mode: concgroup.Shared}}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cg.GoMultiAccess(shared, func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	var wrote atomic.Bool
+	cg.GoMultiAccess([]concgroup.KeyAccess{{Key: "config", Mode: concgroup.Exclusive}}, func() error {
+		wrote.Store(true)
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+	var readBeforeWrite atomic.Bool
+	cg.GoMultiAccess(shared, func() error {
+		if !wrote.Load() {
+			readBeforeWrite.Store(true)
+		}
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if readBeforeWrite.Load() {
+		t.Error("a later shared function overtook a waiting exclusive one")
+	}
+}
+
+func TestGoSharedExclusive(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	c := &rwChecker{readers: map[string]int{}, writers: map[string]int{}}
+	var maxReaders int64
+	var readers int64
+	for i := 0; i < 50; i++ {
+		switch i % 5 {
+		case 0:
+			a := []concgroup.KeyAccess{{Key: "doc", Mode: concgroup.Exclusive}}
+			cg.GoExclusive("doc", func() error {
+				if err := c.enter(a); err != nil {
+					return err
+				}
+				defer c.leave(a)
+				time.Sleep(time.Millisecond)
+				return nil
+			})
+		case 1:
+			a := []concgroup.KeyAccess{{Key: "doc", Mode: concgroup.Shared}, {Key: "index", Mode: concgroup.Shared}}
+			cg.GoMultiShared([]string{"doc", "index"}, func() error {
+				if err := c.enter(a); err != nil {
+					return err
+				}
+				defer c.leave(a)
+				time.Sleep(time.Millisecond)
+				return nil
+			})
+		default:
+			a := []concgroup.KeyAccess{{Key: "doc", Mode: concgroup.Shared}}
+			cg.GoShared("doc", func() error {
+				if err := c.enter(a); err != nil {
+					return err
+				}
+				defer c.leave(a)
+				n := atomic.AddInt64(&readers, 1)
+				defer atomic.AddInt64(&readers, -1)
+				for {
+					m := atomic.LoadInt64(&maxReaders)
+					if n <= m || atomic.CompareAndSwapInt64(&maxReaders, m, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				return nil
+			})
+		}
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxReaders < 2 {
+		t.Errorf("got max %d readers, want readers to run at the same time", maxReaders)
+	}
+}
@@ -0,0 +1,26 @@
+package concgroup
+
+import (
+	"context"
+)
+
+// Func is the constraint of the function signatures accepted by Adapt.
+type Func interface {
+	func() error | func(ctx context.Context) error
+}
+
+// Adapt adapts f of either signature to a function taking a context, so that both can be submitted
+// through GoCtx and the other methods taking a context without writing a wrapping closure.
+//
+//	cg.GoCtx("key", concgroup.Adapt(func() error { ... }))
+func Adapt[F Func](f F) func(ctx context.Context) error {
+	switch fn := any(f).(type) {
+	case func() error:
+		return func(_ context.Context) error {
+			return fn()
+		}
+	case func(ctx context.Context) error:
+		return fn
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,32 @@
+package concgroup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestAdapt(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	called := make(chan string, 2)
+	cg.GoCtx("samegroup", concgroup.Adapt(func() error {
+		called <- "func() error"
+		return nil
+	}))
+	cg.GoCtx("samegroup", concgroup.Adapt(func(ctx context.Context) error {
+		if ctx == nil {
+			t.Error("want context")
+		}
+		called <- "func(ctx context.Context) error"
+		return nil
+	}))
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	close(called)
+	if len(called) != 2 {
+		t.Errorf("got %d calls, want 2", len(called))
+	}
+}
@@ -0,0 +1,137 @@
+package concgroup
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// SetKeyAffinity sets whether functions with a single key are called by workers of the key instead of
+// a goroutine per function. In the mode, the functions of a key are queued, and up to the limit of the key
+// (1 unless set by SetKeyLimit) long-lived workers call them one after another in the order of priority and
+// submission while the key has queued functions, so that a hot key does not park a goroutine per function.
+// Functions with multiple keys or shared keys, and functions while replaying a schedule, are still called by
+// a goroutine each. It must be called before any function is submitted.
+func (g *Group) SetKeyAffinity(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.affinity = on
+}
+
+// dispatch calls f of s in a new goroutine, or queues it for the workers of its key in key affinity mode.
+func (g *Group) dispatch(s spec, f func(ctx context.Context) error) {
+	if !g.affinity || len(s.keys) != 1 || len(s.modes) != 0 || g.replay.enabled() {
+		g.spawn(g.locks.newTask(g.ctx, s, !g.replay.enabled()), f)
+		return
+	}
+	// The task waits for the key lock only once a worker picks it, so that a queued task does not keep the lock
+	// from the task the worker is calling.
+	t := g.locks.newTask(g.ctx, s, false)
+	g.emit(Event{Kind: EventSubmitted, Keys: t.keys, Seq: t.seq})
+	key := t.keys[0]
+	n := g.locks.capacity(key)
+	if g.workers.push(key, n, g.locks.agingPeriod(), work{t: t, f: f}) {
+		g.eg.Go(func() error {
+			g.work(key)
+			return nil
+		})
+	}
+}
+
+// work calls the functions queued for key until the queue is empty.
+func (g *Group) work(key string) {
+	for {
+		w, ok := g.workers.pop(key)
+		if !ok {
+			return
+		}
+		err := g.execute(w.t, w.f)
+		g.releaseSlots(&w.t.spec)
+		if err != nil {
+			// Report the error right away as the goroutine of the function would do.
+			g.eg.Go(func() error {
+				return err
+			})
+		}
+	}
+}
+
+// capacity returns the number of tasks that can hold the lock of key at the same time.
+func (lt *lockTable) capacity(key string) int {
+	defer lt.lockKeys([]string{key}, false)()
+	return lt.lock(key).capacity()
+}
+
+// workers is the queues of the functions of keys in key affinity mode.
+type workers struct {
+	mu     sync.Mutex
+	queues map[string]*workQueue
+}
+
+type work struct {
+	t *task
+	f func(ctx context.Context) error
+}
+
+// workQueue is the functions of a key ordered by priority and submission, and the number of its workers.
+type workQueue struct {
+	works   []work
+	workers int
+	aging   time.Duration
+}
+
+func (q *workQueue) Len() int { return len(q.works) }
+
+func (q *workQueue) Less(i, j int) bool {
+	a, b := q.works[i].t, q.works[j].t
+	return precedes(a, b, a.priority, b.priority, q.aging)
+}
+
+func (q *workQueue) Swap(i, j int) { q.works[i], q.works[j] = q.works[j], q.works[i] }
+
+func (q *workQueue) Push(x any) { q.works = append(q.works, x.(work)) }
+
+func (q *workQueue) Pop() any {
+	n := len(q.works) - 1
+	w := q.works[n]
+	q.works[n] = work{}
+	q.works = q.works[:n]
+	return w
+}
+
+// push queues w for key with priorities aged by aging, and reports whether a new worker is needed
+// as the key has less than n workers.
+func (ws *workers) push(key string, n int, aging time.Duration, w work) bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	q, ok := ws.queues[key]
+	if !ok {
+		if ws.queues == nil {
+			ws.queues = map[string]*workQueue{}
+		}
+		q = &workQueue{aging: aging}
+		ws.queues[key] = q
+	}
+	heap.Push(q, w)
+	if q.workers >= n {
+		return false
+	}
+	q.workers++
+	return true
+}
+
+// pop returns the next function of key, or retires the worker calling it if there is none.
+func (ws *workers) pop(key string) (work, bool) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	q := ws.queues[key]
+	if q.Len() == 0 {
+		q.workers--
+		if q.workers == 0 {
+			delete(ws.queues, key)
+		}
+		return work{}, false
+	}
+	return heap.Pop(q).(work), true
+}
@@ -0,0 +1,137 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetKeyAffinity(t *testing.T) {
+	cg := &concgroup.Group{}
+	cg.SetKeyAffinity(true)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("hot", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	before := runtime.NumGoroutine()
+	var mu sync.Mutex
+	var got []int
+	for i := 0; i < 1000; i++ {
+		i := i
+		cg.Go("hot", func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, i)
+			return nil
+		})
+	}
+	if n := runtime.NumGoroutine() - before; n > 10 {
+		t.Errorf("got %d more goroutines for queued functions of a key", n)
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("got %v at %d, want the order of submission", v, i)
+		}
+	}
+}
+
+func TestSetKeyAffinityKeyLimit(t *testing.T) {
+	cg := &concgroup.Group{}
+	cg.SetKeyAffinity(true)
+	cg.SetKeyLimit("key", 2)
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	var once sync.Once
+	for i := 0; i < 10; i++ {
+		cg.Go("key", func() error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			if n == 2 {
+				once.Do(func() { close(release) })
+			}
+			<-release
+			running.Add(-1)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("got %d running at the same time, want %d", got, 2)
+	}
+}
+
+func TestSetKeyAffinityPriority(t *testing.T) {
+	cg := &concgroup.Group{}
+	cg.SetKeyAffinity(true)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("key", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	var mu sync.Mutex
+	var got []int
+	for _, p := range []int{0, 2, 1} {
+		p := p
+		cg.GoPriority("key", p, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, p)
+			return nil
+		})
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 1, 0}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSetKeyAffinityError(t *testing.T) {
+	cg, ctx := concgroup.WithContext(context.Background())
+	cg.SetKeyAffinity(true)
+	cg.SetFailFast(true)
+	failed := errors.New("failed")
+	var called atomic.Int32
+	cg.Go("key", func() error {
+		return failed
+	})
+	for i := 0; i < 10; i++ {
+		cg.Go("key", func() error {
+			<-ctx.Done()
+			called.Add(1)
+			return nil
+		})
+	}
+	if err := cg.Wait(); !errors.Is(err, failed) {
+		t.Errorf("got %v, want %v", err, failed)
+	}
+	if got := called.Load(); got != 0 {
+		t.Errorf("got %d calls after the error, want %d", got, 0)
+	}
+}
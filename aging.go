@@ -0,0 +1,37 @@
+package concgroup
+
+import (
+	"time"
+)
+
+// SetPriorityAging makes a function waiting for its key locks gain 1 priority for every period of waiting,
+// so that low priority functions are eventually called while higher priority functions keep coming.
+// For example, with SetPriorityAging(time.Second), a function of priority 0 waiting for 3 seconds takes precedence
+// over a function of priority 2 submitted just now. Zero, the default, disables aging.
+func (g *Group) SetPriorityAging(period time.Duration) {
+	g.init()
+	g.locks.mu.Lock()
+	defer g.locks.mu.Unlock()
+	g.locks.aging = period
+}
+
+// precedes reports whether a takes precedence over b with priorities pa and pb aged by period.
+// As all waiting tasks age at the same rate, the order does not change over time: a comes first if its priority
+// exceeds that of b by more than the priority b has gained by waiting longer than a.
+func precedes(a, b *task, pa, pb int, period time.Duration) bool {
+	d := float64(pa - pb)
+	if period > 0 {
+		d -= float64(a.submitted.Sub(b.submitted)) / float64(period)
+	}
+	if d != 0 {
+		return d > 0
+	}
+	return a.seq < b.seq
+}
+
+// agingPeriod returns the period set by SetPriorityAging.
+func (lt *lockTable) agingPeriod() time.Duration {
+	lt.mu.RLock()
+	defer lt.mu.RUnlock()
+	return lt.aging
+}
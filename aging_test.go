@@ -0,0 +1,48 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetPriorityAging(t *testing.T) {
+	t.Parallel()
+	for _, affinity := range []bool{false, true} {
+		cg := new(concgroup.Group)
+		cg.SetKeyAffinity(affinity)
+		cg.SetPriorityAging(10 * time.Millisecond)
+		block := make(chan struct{})
+		started := make(chan struct{})
+		cg.Go("key", func() error {
+			close(started)
+			<-block
+			return nil
+		})
+		<-started
+		var mu sync.Mutex
+		var got []string
+		record := func(name string) func() error {
+			return func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, name)
+				return nil
+			}
+		}
+		cg.GoPriority("key", 0, record("old-background"))
+		time.Sleep(50 * time.Millisecond)
+		cg.GoPriority("key", 2, record("new-urgent"))
+		cg.GoPriority("key", 9, record("new-critical"))
+		close(block)
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if want := "[new-critical old-background new-urgent]"; fmt.Sprint(got) != want {
+			t.Errorf("got %v, want %s (affinity %v)", got, want, affinity)
+		}
+	}
+}
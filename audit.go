@@ -0,0 +1,176 @@
+package concgroup
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// LockOrderViolation is a pair of keys locked in inconsistent orders found by SetLockOrderAuditor.
+type LockOrderViolation struct {
+	// First is the key held while locking Second at Site.
+	First string
+	// Second is the key locked at Site while holding First.
+	// It is held while locking First, directly or through other keys, at PriorSite.
+	// It is the same as First when a function locks a key it already holds.
+	Second string
+	// Site is the call site of the submission or LockKey locking Second, such as "/src/app/main.go:42".
+	Site string
+	// PriorSite is the call site that has locked the keys in the other order.
+	PriorSite string
+}
+
+func (v *LockOrderViolation) String() string {
+	if v.First == v.Second {
+		return fmt.Sprintf("concgroup: %s locked at %s while held since %s", v.First, v.Site, v.PriorSite)
+	}
+	return fmt.Sprintf("concgroup: %s locked after %s at %s, but before it at %s", v.Second, v.First, v.Site, v.PriorSite)
+}
+
+// SetLockOrderAuditor makes the group record the order in which the call sites of GoMulti and of LockKey and
+// LockMultiKey called with the context of a function lock keys, like lockdep of Linux, and call warn when two call sites
+// lock keys in inconsistent orders that could deadlock, even if they have not deadlocked yet.
+// The keys of GoMulti are locked in sorted order, so inconsistencies come from functions calling LockKey with their
+// contexts while holding their own keys. With AllOrNothing, the keys of a function are not ordered among themselves.
+// It is meant for debugging and tests, as it keeps the order of all keys ever locked together and captures
+// the call site of every submission. A nil warn disables the auditor.
+func (g *Group) SetLockOrderAuditor(warn func(v *LockOrderViolation)) {
+	g.init()
+	if warn == nil {
+		g.locks.auditor.Store(nil)
+		return
+	}
+	g.locks.auditor.Store(&auditor{warn: warn, order: map[string]map[string]string{}})
+}
+
+// auditor records the order of keys set by SetLockOrderAuditor.
+type auditor struct {
+	warn func(v *LockOrderViolation)
+	mu   sync.Mutex
+	// order is the call site that first locked the key of the second map after the key of the first map.
+	order map[string]map[string]string
+}
+
+// heldKey is a key held at a call site.
+type heldKey struct {
+	key  string
+	site string
+}
+
+// audit records the order in which t locks its keys, after the keys held by the functions calling LockKey.
+func (lt *lockTable) audit(t *task) {
+	a := lt.auditor.Load()
+	if a == nil || len(t.keys) == 0 {
+		return
+	}
+	var held []heldKey
+	for c := t.caller; c != nil; c = c.caller {
+		for _, key := range c.keys {
+			held = append(held, heldKey{key: key, site: c.site})
+		}
+	}
+	lt.mu.RLock()
+	ordered := lt.strategy != AllOrNothing
+	lt.mu.RUnlock()
+	var violations []*LockOrderViolation
+	a.mu.Lock()
+	for _, h := range held {
+		for i, key := range t.keys {
+			if i > 0 && ordered {
+				break
+			}
+			if key == h.key {
+				violations = append(violations, &LockOrderViolation{First: key, Second: key, Site: t.site, PriorSite: h.site})
+				continue
+			}
+			if v := a.record(h.key, key, t.site); v != nil {
+				violations = append(violations, v)
+			}
+		}
+	}
+	if ordered {
+		for i := 1; i < len(t.keys); i++ {
+			if v := a.record(t.keys[i-1], t.keys[i], t.site); v != nil {
+				violations = append(violations, v)
+			}
+		}
+	}
+	a.mu.Unlock()
+	for _, v := range violations {
+		a.warn(v)
+	}
+}
+
+// record records that second is locked after first at site,
+// and returns the violation if second has been locked before first at another call site.
+func (a *auditor) record(first, second, site string) *LockOrderViolation {
+	if _, ok := a.order[first][second]; ok {
+		return nil
+	}
+	prior := a.path(second, first)
+	if a.order[first] == nil {
+		a.order[first] = map[string]string{}
+	}
+	a.order[first][second] = site
+	if prior == "" {
+		return nil
+	}
+	return &LockOrderViolation{First: first, Second: second, Site: site, PriorSite: prior}
+}
+
+// path returns the call site of the first order of a path from the key from to the key to, or "" if none.
+func (a *auditor) path(from, to string) string {
+	visited := map[string]bool{from: true}
+	var walk func(key string) bool
+	walk = func(key string) bool {
+		for next := range a.order[key] {
+			if next == to {
+				return true
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			if walk(next) {
+				return true
+			}
+		}
+		return false
+	}
+	for next, site := range a.order[from] {
+		if next == to {
+			return site
+		}
+		if visited[next] {
+			continue
+		}
+		visited[next] = true
+		if walk(next) {
+			return site
+		}
+	}
+	return ""
+}
+
+// pkgPrefix is the prefix of the functions of this package.
+var pkgPrefix = reflect.TypeOf((*Group)(nil)).Elem().PkgPath() + "."
+
+// callSite returns the file and line of the caller of this package, if the auditor is enabled.
+func (lt *lockTable) callSite() string {
+	if lt.auditor.Load() == nil {
+		return ""
+	}
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package concgroup_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetLockOrderAuditor(t *testing.T) {
+	tests := []struct {
+		name  string
+		first func(cg *concgroup.Group, lock func(key string) func(ctx context.Context) error)
+	}{
+		{
+			name: "LockKey",
+			first: func(cg *concgroup.Group, lock func(key string) func(ctx context.Context) error) {
+				cg.GoCtx("a", lock("b"))
+			},
+		},
+		{
+			name: "GoMulti",
+			first: func(cg *concgroup.Group, lock func(key string) func(ctx context.Context) error) {
+				cg.GoMulti([]string{"b", "a"}, func() error { return nil })
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cg := &concgroup.Group{}
+			var mu sync.Mutex
+			var got []*concgroup.LockOrderViolation
+			cg.SetLockOrderAuditor(func(v *concgroup.LockOrderViolation) {
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, v)
+			})
+			lock := func(key string) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					unlock, err := cg.LockKey(ctx, key)
+					if err != nil {
+						return err
+					}
+					unlock()
+					return nil
+				}
+			}
+			tt.first(cg, lock)
+			if err := cg.Wait(); err != nil {
+				t.Fatal(err)
+			}
+			cg.GoCtx("b", lock("a"))
+			cg.GoCtx("b", lock("a"))
+			if err := cg.Wait(); err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(got) != 1 {
+				t.Fatalf("got %d violations, want 1", len(got))
+			}
+			v := got[0]
+			if v.First != "b" || v.Second != "a" {
+				t.Errorf("got %s after %s, want a after b", v.Second, v.First)
+			}
+			if !strings.Contains(v.Site, "audit_test.go:") || !strings.Contains(v.PriorSite, "audit_test.go:") {
+				t.Errorf("got %s and %s, want call sites in the test", v.Site, v.PriorSite)
+			}
+		})
+	}
+}
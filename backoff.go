@@ -0,0 +1,98 @@
+package concgroup
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SetKeyBackoff makes the group delay the next function with a key after functions with the key fail consecutively,
+// such as when the downstream of a shard is flaky. The delay starts at initial and doubles per consecutive failure
+// up to max, and is randomized by up to jitter (0 to 1) of it. It is reset when a function with the key succeeds.
+// The delay is taken while holding the key locks, so functions with other keys are unaffected.
+// A zero or negative initial indicates no backoff.
+func (g *Group) SetKeyBackoff(initial, max time.Duration, jitter float64) {
+	g.backoff.mu.Lock()
+	defer g.backoff.mu.Unlock()
+	g.backoff.initial = initial
+	g.backoff.max = max
+	g.backoff.jitter = jitter
+}
+
+// backoff delays functions with keys failing consecutively.
+type backoff struct {
+	mu      sync.Mutex
+	initial time.Duration
+	max     time.Duration
+	jitter  float64
+	// failures is the number of consecutive failures of each key.
+	failures lru[*int]
+}
+
+// delay returns the delay before calling a function with keys, which is the longest delay of them.
+func (b *backoff) delay(keys []string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.initial <= 0 {
+		return 0
+	}
+	n := 0
+	for _, key := range keys {
+		if f, ok := b.failures.lookup(key); ok && *f > n {
+			n = *f
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	d := b.initial
+	for i := 1; i < n && (b.max <= 0 || d < b.max); i++ {
+		d *= 2
+	}
+	if b.max > 0 && d > b.max {
+		d = b.max
+	}
+	if b.jitter > 0 {
+		d += time.Duration(b.jitter * (2*rand.Float64() - 1) * float64(d)) //nolint:gosec
+	}
+	return d
+}
+
+// wait blocks for the delay before calling a function with keys, or until ctx is done.
+func (b *backoff) wait(ctx context.Context, keys []string) error {
+	d := b.delay(keys)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record records the result of a function, counting the consecutive failures of its keys.
+func (b *backoff) record(r TaskResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.initial <= 0 || r.Canceled {
+		return
+	}
+	for _, key := range r.Keys {
+		if r.Err == nil {
+			b.failures.remove(key)
+			continue
+		}
+		*b.failures.get(key, func() *int { return new(int) })++
+	}
+}
+
+func (b *backoff) setKeyCacheSize(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures.setSize(n)
+}
@@ -0,0 +1,53 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestKeyBackoff(t *testing.T) {
+	t.Parallel()
+	const initial = 50 * time.Millisecond
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	cg.SetKeyBackoff(initial, 2*initial, 0)
+	var (
+		mu    sync.Mutex
+		calls = map[string][]time.Time{}
+	)
+	call := func(key string, err error) func() error {
+		return func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[key] = append(calls[key], time.Now())
+			return err
+		}
+	}
+	errFlaky := errors.New("flaky")
+	cg.Go("flaky", call("flaky", errFlaky))
+	cg.Go("flaky", call("flaky", errFlaky))
+	cg.Go("flaky", call("flaky", nil))
+	cg.Go("flaky", call("flaky", nil))
+	cg.Go("stable", call("stable", nil))
+	cg.Go("stable", call("stable", nil))
+	if err := cg.Wait(); !errors.Is(err, errFlaky) {
+		t.Fatalf("got %v, want %v", err, errFlaky)
+	}
+	flaky := calls["flaky"]
+	for i, want := range []time.Duration{initial, 2 * initial} {
+		if got := flaky[i+1].Sub(flaky[i]); got < want {
+			t.Errorf("got delay %v after failure %d, want at least %v", got, i+1, want)
+		}
+	}
+	if got := flaky[3].Sub(flaky[2]); got >= initial {
+		t.Errorf("got delay %v after success, want less than %v", got, initial)
+	}
+	stable := calls["stable"]
+	if got := stable[1].Sub(stable[0]); got >= initial {
+		t.Errorf("got delay %v of other key, want less than %v", got, initial)
+	}
+}
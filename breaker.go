@@ -0,0 +1,79 @@
+package concgroup
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the error of a function with a key whose circuit breaker is open by SetKeyBreaker.
+var ErrCircuitOpen = errors.New("concgroup: circuit open")
+
+// SetKeyBreaker trips the circuit breaker of a key after n consecutive failures of functions with the key,
+// such as when the backend of a tenant is down. While the breaker is open, functions with the key fail fast with
+// ErrCircuitOpen, both when submitted and when they are about to be called after waiting for the key locks,
+// so that the queue of the key drains instead of piling up. After coolDown, functions with the key are called again:
+// a success closes the breaker, and a failure opens it for another coolDown.
+// A value less than 1 indicates no breaker.
+func (g *Group) SetKeyBreaker(n int, coolDown time.Duration) {
+	g.breaker.mu.Lock()
+	defer g.breaker.mu.Unlock()
+	g.breaker.n = n
+	g.breaker.coolDown = coolDown
+}
+
+// breaker is the circuit breakers of keys.
+type breaker struct {
+	mu       sync.Mutex
+	n        int
+	coolDown time.Duration
+	circuits lru[*circuit]
+}
+
+type circuit struct {
+	// failures is the number of consecutive failures.
+	failures  int
+	openUntil time.Time
+}
+
+// check returns ErrCircuitOpen if the breaker of any of keys is open.
+func (b *breaker) check(keys []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.n < 1 {
+		return nil
+	}
+	now := time.Now()
+	for _, key := range keys {
+		if c, ok := b.circuits.lookup(key); ok && now.Before(c.openUntil) {
+			return ErrCircuitOpen
+		}
+	}
+	return nil
+}
+
+// record records the result of a function, tripping the breakers of its keys failing consecutively.
+func (b *breaker) record(r TaskResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.n < 1 || r.Canceled || errors.Is(r.Err, ErrCircuitOpen) {
+		return
+	}
+	for _, key := range r.Keys {
+		if r.Err == nil {
+			b.circuits.remove(key)
+			continue
+		}
+		c := b.circuits.get(key, func() *circuit { return &circuit{} })
+		c.failures++
+		if c.failures >= b.n {
+			c.openUntil = time.Now().Add(b.coolDown)
+		}
+	}
+}
+
+func (b *breaker) setKeyCacheSize(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.circuits.setSize(n)
+}
@@ -0,0 +1,83 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestKeyBreaker(t *testing.T) {
+	t.Parallel()
+	const coolDown = 100 * time.Millisecond
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	cg.SetKeyBreaker(2, coolDown)
+	var (
+		mu      sync.Mutex
+		results []error
+	)
+	cg.OnResult(func(r concgroup.TaskResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, r.Err)
+	})
+	errDown := errors.New("backend down")
+	called := 0
+	for i := 0; i < 4; i++ {
+		// The functions after the second failure fail fast while waiting for the key lock.
+		cg.Go("tenant-a", func() error {
+			called++
+			return errDown
+		})
+	}
+	otherCalled := false
+	cg.Go("tenant-b", func() error {
+		otherCalled = true
+		return nil
+	})
+	if err := cg.Wait(); !errors.Is(err, errDown) {
+		t.Fatalf("got %v, want %v", err, errDown)
+	}
+	if called != 2 {
+		t.Errorf("got %d calls, want 2", called)
+	}
+	if !otherCalled {
+		t.Error("function with other key is not called")
+	}
+	open := 0
+	for _, err := range results {
+		if errors.Is(err, concgroup.ErrCircuitOpen) {
+			open++
+		}
+	}
+	if open != 2 {
+		t.Errorf("got %d results with %v, want 2", open, concgroup.ErrCircuitOpen)
+	}
+
+	// Submissions fail fast while the breaker is open.
+	cg.Go("tenant-a", func() error {
+		t.Error("function is called while the breaker is open")
+		return nil
+	})
+	if err := cg.Wait(); !errors.Is(err, concgroup.ErrCircuitOpen) {
+		t.Errorf("got %v, want %v", err, concgroup.ErrCircuitOpen)
+	}
+
+	// After the cool-down, a success closes the breaker.
+	time.Sleep(coolDown)
+	for i := 0; i < 2; i++ {
+		cg.Go("tenant-a", func() error {
+			called++
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if called != 4 {
+		t.Errorf("got %d calls, want 4", called)
+	}
+}
@@ -0,0 +1,50 @@
+package concgroup
+
+import (
+	"errors"
+	"sync"
+)
+
+// SetMaxErrors sets the error budget of the run. Once n functions have returned an error, the remaining functions
+// (including the ones submitted later) are canceled, and Wait returns the n errors joined.
+// If fewer than n functions return an error, no function is canceled, and Wait returns the errors joined.
+// A zero or negative value indicates no budget, and Wait returns the first error like errgroup.Group. The default is 0.
+func (g *Group) SetMaxErrors(n int) {
+	g.budget.mu.Lock()
+	defer g.budget.mu.Unlock()
+	g.budget.max = n
+}
+
+// errorBudget counts the errors of functions up to the limit.
+type errorBudget struct {
+	mu   sync.Mutex
+	max  int
+	errs []error
+}
+
+func (b *errorBudget) enabled() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.max > 0
+}
+
+// add records err, and returns the joined errors if the budget is exhausted by it.
+func (b *errorBudget) add(err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.errs) >= b.max {
+		return nil
+	}
+	b.errs = append(b.errs, err)
+	if len(b.errs) < b.max {
+		return nil
+	}
+	return errors.Join(b.errs...)
+}
+
+// joined returns the errors recorded so far joined, or nil if none.
+func (b *errorBudget) joined() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return errors.Join(b.errs...)
+}
@@ -0,0 +1,65 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetMaxErrors(t *testing.T) {
+	t.Parallel()
+	cg, ctx := concgroup.WithContext(context.Background())
+	cg.SetMaxErrors(3)
+	var called int64
+	for i := 0; i < 100; i++ {
+		i := i
+		cg.Go("samegroup", func() error {
+			atomic.AddInt64(&called, 1)
+			return fmt.Errorf("error %d", i)
+		})
+	}
+	err := cg.Wait()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if got := len(strings.Split(err.Error(), "\n")); got != 3 {
+		t.Errorf("got %v, want 3 errors joined", err)
+	}
+	if got := atomic.LoadInt64(&called); got != 3 {
+		t.Errorf("got %d calls, want 3", got)
+	}
+	if ctx.Err() == nil {
+		t.Error("want context canceled")
+	}
+}
+
+func TestSetMaxErrorsNotExhausted(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetMaxErrors(3)
+	for i := 0; i < 2; i++ {
+		cg.Go("samegroup", func() error {
+			return errors.New("task error")
+		})
+	}
+	called := false
+	cg.Go("samegroup", func() error {
+		called = true
+		return nil
+	})
+	err := cg.Wait()
+	if err == nil {
+		t.Fatal("got nil, want the errors within the budget")
+	}
+	if got := strings.Count(err.Error(), "task error"); got != 2 {
+		t.Errorf("got %d errors, want 2", got)
+	}
+	if !called {
+		t.Error("function is canceled before the budget is exhausted")
+	}
+}
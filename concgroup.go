@@ -2,117 +2,690 @@ package concgroup
 
 import (
 	"context"
+	"errors"
+	"runtime/debug"
+	"runtime/pprof"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 )
 
 // Group is a collection of goroutines like errgroup.Group.
 type Group struct {
-	eg       *errgroup.Group
-	mu       sync.Mutex
-	locks    map[string]*sync.Mutex
-	initOnce sync.Once
+	eg  *errgroup.Group
+	ctx context.Context
+	// parent is the context given to WithContext, from which Reset derives the context of a new run.
+	parent  context.Context
+	limiter *limiter
+	// mu is read-locked by submissions so that producers submit concurrently, and write-locked to configure or close the group.
+	mu            sync.RWMutex
+	locks         *lockTable
+	subs          subscriptions
+	stats         stats
+	pacer         pacer
+	backoff       backoff
+	rates         keyRates
+	breaker       breaker
+	dag           dag
+	dedup         dedup
+	workers       workers
+	resources     resources
+	closed        bool
+	opened        chan struct{}
+	closeOnCancel bool
+	affinity      bool
+	limitPolicy   LimitPolicy
+	turns         turns
+	emptyKey      EmptyKeyPolicy
+	validateKey   func(key string) error
+	sem           externalSemaphore
+	locker        KeyLocker
+	limitPatterns []limitPattern
+	sinks         sinks
+	replay        replayer
+	handlers      handlers
+	progress      progress
+	waitMu        sync.Mutex
+	pending       *pendingWait
+	tracer        Tracer
+	onError       func(TaskResult)
+	budget        errorBudget
+	failures      failures
+	keyErrs       keyErrors
+	failFast      atomic.Bool
+	failed        atomic.Bool
+	recovered     *RecoveredPanic
+	panicAsError  atomic.Bool
+	panicMu       sync.Mutex
+	initOnce      sync.Once
 }
 
 // WithContext returns a new Group and an associated Context like errgroup.Group.
 func WithContext(ctx context.Context) (*Group, context.Context) {
-	eg, ctx := errgroup.WithContext(ctx)
-	return &Group{eg: eg}, ctx
+	eg, gctx := errgroup.WithContext(ctx)
+	return &Group{eg: eg, ctx: gctx, parent: ctx}, gctx
+}
+
+// FromErrgroup returns a new Group that calls functions in eg, to add keys to existing errgroup-based code incrementally.
+// Functions called by the Group and by eg directly share the first error returned by eg.Wait (and Wait of the Group).
+// The limit set by eg.SetLimit still applies in addition to the limit of the Group.
+func FromErrgroup(eg *errgroup.Group) *Group {
+	return &Group{eg: eg}
 }
 
 // Go calls the given function in a new goroutine like errgroup.Group with key.
+// Functions with the same key are called one at a time in the order of submission.
+// The key locks are fair: a function is queued for its key when it is submitted, so under heavy contention a newly
+// submitted function never goes ahead of older ones as it could with sync.Mutex.
 func (g *Group) Go(key string, f func() error) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	g.init()
-	mu, ok := g.locks[key]
-	if !ok {
-		mu = &sync.Mutex{}
-		g.locks[key] = mu
-	}
-	g.eg.Go(func() error {
-		mu.Lock()
-		defer mu.Unlock()
-		return f()
-	})
+	g.submit(spec{keys: []string{key}}, Adapt(f))
 }
 
 // GoMulti calls the given function in a new goroutine like errgroup.Group with multiple key locks.
+// The keys are locked in sorted order without duplicates, so GoMulti calls with overlapping keys never deadlock.
 func (g *Group) GoMulti(keys []string, f func() error) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	g.init()
-	sort.Strings(keys)
-	var mus []*sync.Mutex
-	for _, key := range keys {
-		mu, ok := g.locks[key]
-		if !ok {
-			mu = &sync.Mutex{}
-			g.locks[key] = mu
-		}
-		mus = append(mus, mu)
-	}
-	g.eg.Go(func() error {
-		for _, mu := range mus {
-			mu.Lock()
-			defer mu.Unlock()
-		}
-		return f()
-	})
+	g.submit(spec{keys: sortedKeys(keys)}, Adapt(f))
+}
+
+// GoPriority calls the given function in a new goroutine like Go with priority.
+// While waiting for the key lock, a goroutine with higher priority takes precedence over others,
+// and goroutines with the same priority take the lock in the order of submission.
+// A goroutine holding a key lock inherits the priority of higher priority goroutines waiting for it.
+func (g *Group) GoPriority(key string, priority int, f func() error) {
+	g.submit(spec{keys: []string{key}, priority: priority}, Adapt(f))
+}
+
+// GoMultiPriority calls the given function in a new goroutine like GoMulti with priority.
+func (g *Group) GoMultiPriority(keys []string, priority int, f func() error) {
+	g.submit(spec{keys: sortedKeys(keys), priority: priority}, Adapt(f))
+}
+
+// GoCtx calls the given function in a new goroutine like Go, passing a context derived from the context of the group.
+// The context is canceled when the context of the group is canceled (by the first error for a group made by WithContext),
+// when the function is canceled by CancelKeys or CancelMatching, and after the function returns.
+func (g *Group) GoCtx(key string, f func(ctx context.Context) error) {
+	g.submit(spec{keys: []string{key}}, f)
+}
+
+// GoTimeout calls the given function in a new goroutine like GoCtx with the timeout.
+// The timeout covers both waiting for the key lock and the call, and the function is not called if it expires while waiting.
+func (g *Group) GoTimeout(key string, timeout time.Duration, f func(ctx context.Context) error) {
+	g.submit(spec{keys: []string{key}, timeout: timeout}, f)
+}
+
+// GoMultiCtx calls the given function in a new goroutine like GoMulti, passing a context derived from the context of the group.
+func (g *Group) GoMultiCtx(keys []string, f func(ctx context.Context) error) {
+	g.submit(spec{keys: sortedKeys(keys)}, f)
+}
+
+// GoWeighted calls the given function in a new goroutine like Go, consuming weight slots of the limit set by SetLimit
+// instead of one, so that heavy functions count more. A weight larger than the limit runs alone.
+func (g *Group) GoWeighted(key string, weight int, f func() error) {
+	g.submit(spec{keys: []string{key}, slots: weight}, Adapt(f))
+}
+
+// GoMultiWeighted calls the given function in a new goroutine like GoMulti, consuming weight slots of the limit like GoWeighted.
+func (g *Group) GoMultiWeighted(keys []string, weight int, f func() error) {
+	g.submit(spec{keys: sortedKeys(keys), slots: weight}, Adapt(f))
 }
 
 // TryGo calls the given function only when the number of active goroutines is currently below the configured limit like errgroup.Group with key.
 func (g *Group) TryGo(key string, f func() error) bool {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	g.init()
-	mu, ok := g.locks[key]
-	if !ok {
-		mu = &sync.Mutex{}
-		g.locks[key] = mu
-	}
-	return g.eg.TryGo(func() error {
-		mu.Lock()
-		defer mu.Unlock()
-		return f()
-	})
+	return g.trySubmit(spec{keys: []string{key}}, Adapt(f), false)
 }
 
 // TryGoMulti calls the given function only when the number of active goroutines is currently below the configured limit like errgroup.Group with multiple key locks.
 func (g *Group) TryGoMulti(keys []string, f func() error) bool {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	g.init()
-	sort.Strings(keys)
-	var mus []*sync.Mutex
-	for _, key := range keys {
-		mu, ok := g.locks[key]
-		if !ok {
-			mu = &sync.Mutex{}
-			g.locks[key] = mu
-		}
-		mus = append(mus, mu)
-	}
-	return g.eg.TryGo(func() error {
-		for _, mu := range mus {
-			mu.Lock()
-			defer mu.Unlock()
-		}
-		return f()
-	})
+	return g.trySubmit(spec{keys: sortedKeys(keys)}, Adapt(f), false)
+}
+
+// TryGoWeighted calls the given function like TryGo only when weight more slots are currently within the limit,
+// consuming them like GoWeighted. A weight larger than the limit is accepted only when no goroutine is active.
+func (g *Group) TryGoWeighted(key string, weight int, f func() error) bool {
+	return g.trySubmit(spec{keys: []string{key}, slots: weight}, Adapt(f), false)
+}
+
+// TryGoMultiWeighted calls the given function like TryGoMulti only when weight more slots are currently within the limit like TryGoWeighted.
+func (g *Group) TryGoMultiWeighted(keys []string, weight int, f func() error) bool {
+	return g.trySubmit(spec{keys: sortedKeys(keys), slots: weight}, Adapt(f), false)
+}
+
+// TryGoCtx calls the given function like TryGo, passing a context derived from the context of the group like GoCtx.
+func (g *Group) TryGoCtx(key string, f func(ctx context.Context) error) bool {
+	return g.trySubmit(spec{keys: []string{key}}, f, false)
+}
+
+// TryGoMultiCtx calls the given function like TryGoMulti, passing a context derived from the context of the group like GoCtx.
+func (g *Group) TryGoMultiCtx(keys []string, f func(ctx context.Context) error) bool {
+	return g.trySubmit(spec{keys: sortedKeys(keys)}, f, false)
+}
+
+// TryGoKey calls the given function only when the key is not held by or waited for by other functions
+// and the number of active goroutines is below the configured limit, for "skip if already running" patterns.
+// The key lock is taken before TryGoKey returns true, so the accepted function does not wait for it.
+func (g *Group) TryGoKey(key string, f func() error) bool {
+	return g.trySubmit(spec{keys: []string{key}}, Adapt(f), true)
+}
+
+// TryGoMultiKey calls the given function like TryGoKey only when none of the keys is held or waited for.
+func (g *Group) TryGoMultiKey(keys []string, f func() error) bool {
+	return g.trySubmit(spec{keys: sortedKeys(keys)}, Adapt(f), true)
 }
 
 // SetLimit limits the number of active goroutines in this group to at most n like errgroup.Group.
+// The limit is a weighted budget: functions submitted by GoWeighted and TryGoWeighted consume their weight of it,
+// such as memory-heavy functions occupying more of the budget, and the others consume 1.
+// A negative value indicates no limit.
 func (g *Group) SetLimit(n int) {
 	g.init()
-	g.eg.SetLimit(n)
+	g.limiter.setLimit(n)
+	g.turns.notify(g.limiter)
+}
+
+// SetRampUp limits the number of active goroutines in this group, raising the limit gradually from `from` to `to`
+// over the duration from the start of a run, to protect cold caches and connection pools from a thundering herd.
+// After the ramp up, it is the same as SetLimit(to).
+func (g *Group) SetRampUp(from, to int, over time.Duration) {
+	g.init()
+	g.limiter.setRampUp(from, to, over)
+	g.turns.notify(g.limiter)
+}
+
+// SetStrategy sets the strategy for acquiring the locks of multiple keys. The default is SortedOrder.
+// It must not be called while goroutines in the group are active.
+func (g *Group) SetStrategy(s Strategy) {
+	g.init()
+	g.locks.mu.Lock()
+	defer g.locks.mu.Unlock()
+	g.locks.strategy = s
+}
+
+// CancelKeys cancels the functions with any of keys.
+// Functions waiting for the key locks return without being called, and the context passed to running functions is canceled.
+// Canceled functions do not make Wait return an error; their results have ErrCanceled or the error they returned.
+func (g *Group) CancelKeys(keys ...string) {
+	g.CancelMatching(func(key string) bool {
+		for _, k := range keys {
+			if k == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// CancelMatching cancels the functions with any key for which match returns true like CancelKeys.
+func (g *Group) CancelMatching(match func(key string) bool) {
+	g.init()
+	g.locks.cancel(g, func(t *task) bool {
+		for _, key := range t.keys {
+			if match(key) {
+				return true
+			}
+		}
+		return false
+	}, ErrCanceled)
+}
+
+// CancelPending cancels the functions with any of keys waiting for the key locks, such as when the resource of
+// the keys is deleted. Running functions are not affected. Their results have ErrCanceled like CancelKeys.
+func (g *Group) CancelPending(keys ...string) {
+	g.cancelPending(keys, ErrCanceled)
+}
+
+// DiscardPending cancels the functions with any of keys waiting for the key locks like CancelPending without reporting
+// them: their results are discarded instead of being passed to Subscribe and OnResult.
+func (g *Group) DiscardPending(keys ...string) {
+	g.cancelPending(keys, errDiscarded)
+}
+
+// errDiscarded is the cause of the cancellation by DiscardPending.
+var errDiscarded = errors.New("concgroup: discarded")
+
+func (g *Group) cancelPending(keys []string, cause error) {
+	g.init()
+	g.locks.cancel(g, func(t *task) bool {
+		if t.running {
+			return false
+		}
+		for _, key := range t.keys {
+			if contains(keys, key) {
+				return true
+			}
+		}
+		return false
+	}, cause)
+}
+
+// Subscribe returns a channel that receives the result of every function with key as it returns.
+// The channel is closed when Wait returns. Results are buffered, so the channel should be drained until it is closed.
+func (g *Group) Subscribe(key string) <-chan TaskResult {
+	return g.subs.add(key)
+}
+
+// Errch returns a channel that receives the errors of functions as they occur, each as a *KeyError with the keys of the function.
+// The channel is closed when Wait returns. Errors are buffered, so the channel should be drained until it is closed.
+func (g *Group) Errch() <-chan error {
+	return g.subs.addErrors()
+}
+
+// OnComplete registers fn to be called with the statistics of the run once when Wait returns.
+func (g *Group) OnComplete(fn func(RunStats)) {
+	g.stats.addOnComplete(fn)
+}
+
+// OnResult registers fn to be called with the result of every function as it returns.
+// fn is called synchronously by the goroutine of the function, so it should return quickly.
+func (g *Group) OnResult(fn func(TaskResult)) {
+	g.AddEventSink(EventSinkFunc(func(e Event) {
+		if e.Kind == EventFinished && !e.Result.Discarded {
+			fn(e.Result)
+		}
+	}))
+}
+
+// OnStart registers fn to be called with the keys of every function right before it is called.
+// fn is called synchronously by the goroutine of the function, so it should return quickly.
+func (g *Group) OnStart(fn func(keys []string)) {
+	g.AddEventSink(EventSinkFunc(func(e Event) {
+		if e.Kind == EventStarted {
+			fn(e.Keys)
+		}
+	}))
+}
+
+// OnError registers fn to be called with the result of every function returning an error or panicking.
+// Canceled functions are not passed to fn like Errch.
+// fn is called synchronously by the goroutine of the function, so it should return quickly.
+func (g *Group) OnError(fn func(TaskResult)) {
+	g.OnResult(func(r TaskResult) {
+		if r.Err != nil && !r.Canceled {
+			fn(r)
+		}
+	})
+}
+
+// Stats returns the current statistics of the functions called by the group.
+func (g *Group) Stats() RunStats {
+	return g.stats.get()
+}
+
+// SetKeyCacheSize limits the per-key state kept apart from the key locks, such as the statistics per key and
+// the failures counted by SetKeyBreaker and SetKeyBackoff, to the n most recently used keys. The state of the least
+// recently used keys is evicted, and rebuilt from scratch when used again.
+// A negative value indicates no limit, and zero restores the default of 10000 keys.
+func (g *Group) SetKeyCacheSize(n int) {
+	g.stats.setKeyCacheSize(n)
+	g.breaker.setKeyCacheSize(n)
+	g.backoff.setKeyCacheSize(n)
 }
 
 // Wait blocks until all function calls from the Go method have returned like errgroup.Group.
+// If a function panicked, Wait panics with the *RecoveredPanic of the first panic after all the others have returned.
 func (g *Group) Wait() error {
-	return g.eg.Wait()
+	w := g.startWait()
+	<-w.done
+	return g.waitResult(w)
+}
+
+// WaitContext blocks until all function calls have returned like Wait, or ctx is done.
+// It returns the error of ctx if it is done first, leaving the functions running so that a later Wait or WaitContext reaps them.
+func (g *Group) WaitContext(ctx context.Context) error {
+	w := g.startWait()
+	select {
+	case <-w.done:
+		return g.waitResult(w)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pendingWait is a wait for the function calls in progress, shared by the callers of Wait and WaitContext.
+type pendingWait struct {
+	done chan struct{}
+	err  error
+}
+
+// startWait starts waiting for the function calls in a new goroutine, or returns the wait in progress.
+func (g *Group) startWait() *pendingWait {
+	g.waitMu.Lock()
+	defer g.waitMu.Unlock()
+	if g.pending != nil {
+		return g.pending
+	}
+	w := &pendingWait{done: make(chan struct{})}
+	g.pending = w
+	go func() {
+		w.err = g.wait()
+		g.waitMu.Lock()
+		g.pending = nil
+		g.waitMu.Unlock()
+		close(w.done)
+	}()
+	return w
+}
+
+// waitResult returns the result of w, panicking with the *RecoveredPanic of the first panic if a function panicked.
+func (g *Group) waitResult(w *pendingWait) error {
+	if p := g.takePanic(); p != nil {
+		panic(p)
+	}
+	return w.err
+}
+
+func (g *Group) wait() error {
+	g.init()
+	defer g.subs.closeAll()
+	err := g.eg.Wait()
+	if ferr := g.failures.take(); err == nil {
+		err = ferr
+	}
+	if err == nil {
+		// The errors within the budget do not cancel the run, but are still reported.
+		err = g.budget.joined()
+	}
+	g.limiter.reset()
+	g.stats.complete()
+	g.progress.write()
+	return err
+}
+
+// submit calls f in a new goroutine with the key locks, blocking until the number of active goroutines is below the limit.
+// It reports whether f is accepted, that is, not rejected or invalid.
+func (g *Group) submit(s spec, f func(ctx context.Context) error) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	g.init()
+	if !s.deferred && g.isClosed() {
+		g.reject(s)
+		return false
+	}
+	if err := g.check(&s); err != nil {
+		g.invalid(s, err)
+		return false
+	}
+	s.sem, s.weight = g.sem.sem, g.sem.weightOf(s.keys)
+	s.locker = g.locker
+	g.applyLimitPatterns(&s)
+	keys, err := g.locks.admit(s.keys, true)
+	if err != nil {
+		g.invalid(s, err)
+		return false
+	}
+	s.keys, s.owner = keys, g
+	s.site = g.locks.callSite()
+	s.roundRobin = g.limitPolicy == LimitRoundRobin
+	s.atTurn = !s.roundRobin && g.replay.enabled()
+	if !s.roundRobin && !s.atTurn {
+		g.limiter.acquire(s.slotsOf())
+	}
+	g.dispatch(s, f)
+	return true
+}
+
+// trySubmit calls f in a new goroutine with the key locks only if the number of active goroutines is below the limit.
+// If hold is true, it also requires the key locks to be free and takes them before returning,
+// except while replaying a schedule, where the order of the schedule takes precedence.
+func (g *Group) trySubmit(s spec, f func(ctx context.Context) error, hold bool) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	g.init()
+	if g.isClosed() {
+		return false
+	}
+	if err := g.check(&s); err != nil {
+		g.invalid(s, err)
+		return false
+	}
+	s.sem, s.weight = g.sem.sem, g.sem.weightOf(s.keys)
+	s.locker = g.locker
+	g.applyLimitPatterns(&s)
+	keys, err := g.locks.admit(s.keys, false)
+	if err != nil {
+		if err != errKeysBusy {
+			g.invalid(s, err)
+		}
+		return false
+	}
+	s.keys, s.owner = keys, g
+	s.site = g.locks.callSite()
+	s.roundRobin = g.limitPolicy == LimitRoundRobin
+	s.atTurn = !s.roundRobin && g.replay.enabled()
+	if (s.roundRobin || s.atTurn) && !g.limiter.available(s.slotsOf()) || !s.roundRobin && !s.atTurn && !g.limiter.tryAcquire(s.slotsOf()) {
+		g.locks.unref(s.keys)
+		return false
+	}
+	if !hold || g.replay.enabled() {
+		g.dispatch(s, f)
+		return true
+	}
+	t := g.locks.tryNewTask(g.ctx, s)
+	if t == nil {
+		g.releaseSlots(&s)
+		return false
+	}
+	g.spawn(t, f)
+	return true
+}
+
+// spawn calls f in a new goroutine while holding the key locks of t.
+func (g *Group) spawn(t *task, f func(ctx context.Context) error) {
+	g.eg.Go(func() error {
+		defer g.releaseSlots(&t.spec)
+		return g.run(t, f)
+	})
+}
+
+// reject records a function submitted after the group is closed.
+func (g *Group) reject(s spec) {
+	g.emit(Event{Kind: EventSubmitted, Keys: s.keys})
+	g.finish(&s, 0, TaskResult{Keys: s.keys, Err: ErrGroupClosed, Canceled: true})
+}
+
+// invalid records a function with invalid keys as failed with err.
+func (g *Group) invalid(s spec, err error) {
+	g.eg.Go(func() error {
+		r := TaskResult{Keys: s.keys, Err: err}
+		g.emit(Event{Kind: EventSubmitted, Keys: s.keys})
+		g.finish(&s, 0, r)
+		return g.handle(r)
+	})
+}
+
+// run calls f while holding the key locks of t.
+func (g *Group) run(t *task, f func(ctx context.Context) error) error {
+	g.emit(Event{Kind: EventSubmitted, Keys: t.keys, Seq: t.seq})
+	return g.execute(t, f)
+}
+
+// execute calls f of submitted t while holding the key locks of t.
+func (g *Group) execute(t *task, f func(ctx context.Context) error) error {
+	// Pass the turn of the replay even if t is not called.
+	defer g.replay.done(t)
+	g.replay.wait(t)
+	if t.atTurn {
+		// Take the slots only now so that the functions before t in the schedule are not kept from them.
+		g.limiter.acquire(t.slotsOf())
+	}
+	g.locks.audit(t)
+	if err := g.locks.acquire(t); err != nil {
+		if d, ok := err.(*DeadlockError); ok {
+			// Fail the function like returning the error, to report the deadlock broken by not calling it.
+			r := TaskResult{Keys: t.keys, Err: d}
+			g.finish(&t.spec, t.seq, r)
+			return g.handle(r)
+		}
+		r := TaskResult{Keys: t.keys, Err: err, Canceled: true}
+		if err == errDiscarded {
+			r.Err, r.Discarded = ErrCanceled, true
+		}
+		g.finish(&t.spec, t.seq, r)
+		return g.abandoned()
+	}
+	if err := g.skip(); err != nil {
+		// Skip the call which is pointless after waiting for the key locks.
+		g.locks.release(t)
+		g.finish(&t.spec, t.seq, TaskResult{Keys: t.keys, Err: err, Canceled: true})
+		return g.abandoned()
+	}
+	r := g.call(t, f)
+	if _, panicked := r.Err.(*RecoveredPanic); r.Err != nil && !r.Canceled && !panicked {
+		r.Err = g.failures.transform(r)
+	}
+	// Record before releasing the key locks so that the next function with the keys backs off or fails fast.
+	g.backoff.record(r)
+	g.breaker.record(r)
+	p, panicked := r.Err.(*RecoveredPanic)
+	if panicked && g.failures.enabled() && g.failures.quarantineOnPanic() {
+		// Quarantine before releasing the key locks so that no waiting function is called.
+		g.locks.quarantineKeys(g, t.keys)
+	}
+	if r.Err != nil && !panicked && g.failures.isolateKeys() {
+		// Cancel before releasing the key locks so that no waiting function with the keys is called.
+		g.locks.cancel(g, func(o *task) bool { return o != t && sharesKey(o.keys, t.keys) }, ErrKeyFailed)
+	}
+	r.Canceled = g.locks.release(t)
+	g.finish(&t.spec, t.seq, r)
+	if panicked && g.onError == nil && !g.failures.enabled() && !g.panicAsError.Load() {
+		// A daemon is never waited on to repanic, so its panics go to onError by handle instead.
+		g.recordPanic(p)
+		return nil
+	}
+	if r.Canceled {
+		return nil
+	}
+	return g.handle(r)
+}
+
+// handle handles the error of a function according to the mode of the group, and returns the error for errgroup.
+func (g *Group) handle(r TaskResult) error {
+	if r.Err == nil {
+		return nil
+	}
+	if g.onError != nil {
+		g.onError(r)
+		return nil
+	}
+	g.keyErrs.add(r)
+	if g.budget.enabled() {
+		err := g.budget.add(r.Err)
+		if err != nil {
+			g.locks.abort(g)
+			g.failed.Store(true)
+		}
+		return err
+	}
+	if g.failures.enabled() {
+		g.failures.add(r)
+		return nil
+	}
+	g.failed.Store(true)
+	return g.failures.keyError(r)
+}
+
+// abandoned returns the error for errgroup of a function which is not called.
+// It is the error of the context of the group if it is done so that Wait reports why the function is abandoned.
+func (g *Group) abandoned() error {
+	if g.ctx.Err() != nil {
+		return context.Cause(g.ctx)
+	}
+	return nil
+}
+
+// skip returns the reason to skip calling a function, or nil if it should be called.
+func (g *Group) skip() error {
+	if g.ctx.Err() != nil {
+		return context.Cause(g.ctx)
+	}
+	if g.failFast.Load() && g.failed.Load() {
+		return ErrSkipped
+	}
+	return nil
+}
+
+// call calls f consuming the resources of t. A panic in f is recovered as a *RecoveredPanic error.
+func (g *Group) call(t *task, f func(ctx context.Context) error) (r TaskResult) {
+	r.Keys = t.keys
+	if t.roundRobin {
+		if err := g.turns.wait(g.limiter, g.locks, t); err != nil {
+			r.Err, r.Canceled = err, true
+			return r
+		}
+		defer g.turns.done(g.limiter, t)
+	}
+	if t.locker != nil && len(t.keys) > 0 {
+		unlock, err := t.locker.Acquire(t.ctx, t.keys)
+		if err != nil {
+			if t.ctx.Err() != nil {
+				r.Err, r.Canceled = g.locks.canceledBy(t), true
+			} else {
+				r.Err = err
+			}
+			return r
+		}
+		defer unlock()
+	}
+	if err := g.resources.acquire(t.resources, t.patterns, t.ctx.Done()); err != nil {
+		if errors.Is(err, ErrCanceled) {
+			err = g.locks.canceledBy(t)
+		}
+		r.Err = err
+		return r
+	}
+	defer g.resources.release(t.resources, t.patterns)
+	if t.weight > 0 {
+		if err := t.sem.Acquire(t.ctx, t.weight); err != nil {
+			r.Err = g.locks.canceledBy(t)
+			return r
+		}
+		defer t.sem.Release(t.weight)
+	}
+	g.pacer.wait()
+	if err := g.breaker.check(t.keys); err != nil {
+		r.Err = err
+		return r
+	}
+	if err := g.backoff.wait(t.ctx, t.keys); err != nil {
+		r.Err, r.Canceled = g.locks.canceledBy(t), true
+		return r
+	}
+	if err := g.rates.wait(t.ctx, t.keys); err != nil {
+		r.Err, r.Canceled = g.locks.canceledBy(t), true
+		return r
+	}
+	g.emit(Event{Kind: EventStarted, Keys: t.keys, Seq: t.seq})
+	g.replay.done(t)
+	defer g.stats.stop()
+	ctx, end := g.startTask(t)
+	start := time.Now()
+	defer func() {
+		r.Duration = time.Since(start)
+		if v := recover(); v != nil {
+			r.Err = &RecoveredPanic{Keys: t.keys, Value: v, Stack: debug.Stack()}
+		}
+		end(r.Err)
+	}()
+	if len(t.keys) == 0 {
+		r.Err = f(ctx)
+		return r
+	}
+	// Label the goroutine with the keys so that profiles and goroutine dumps show which keys it serves.
+	pprof.Do(ctx, pprof.Labels(PprofKeyLabel, strings.Join(t.keys, ",")), func(ctx context.Context) {
+		r.Err = f(ctx)
+	})
+	return r
+}
+
+// finish records the result of a function.
+func (g *Group) finish(s *spec, seq uint64, r TaskResult) {
+	g.emit(Event{Kind: EventFinished, Keys: r.Keys, Seq: seq, Result: r})
+	if s.done != nil {
+		s.done(r)
+	}
 }
 
 func (g *Group) init() {
@@ -120,8 +693,30 @@ func (g *Group) init() {
 		if g.eg == nil {
 			g.eg = &errgroup.Group{}
 		}
+		if g.ctx == nil {
+			g.ctx = context.Background()
+		}
+		if g.limiter == nil {
+			g.limiter = newLimiter()
+		}
 		if g.locks == nil {
-			g.locks = map[string]*sync.Mutex{}
+			g.locks = newLockTable()
 		}
 	})
 }
+
+// sortedKeys returns a sorted copy of keys without duplicates.
+func sortedKeys(keys []string) []string {
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+	n := 0
+	for i, key := range sorted {
+		if i > 0 && key == sorted[n-1] {
+			continue
+		}
+		sorted[n] = key
+		n++
+	}
+	return sorted[:n]
+}
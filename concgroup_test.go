@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/k1LoW/concgroup"
+	"golang.org/x/sync/errgroup"
 )
 
 //nolint:gosec
@@ -216,6 +219,7 @@ func TestConcurrencyGroupMultiAvoidDeadlock(t *testing.T) {
 		{"Order of keys in which deadlock is likely to occur in a and b", append(append([]string{"A0"}, otherKeysA...), "B0"), append(append([]string{"B0"}, otherKeysA...), "A0")},
 		{"Order of keys in which deadlock is likely to occur in a and b", append(append([]string{"A0"}, otherKeysA...), "C0"), append(append([]string{"B0"}, otherKeysB...), "C0")},
 		{"Order of keys in which deadlock is likely to occur in a and b", append(append(append([]string{"A0"}, otherKeysA...), otherKeysB...), "C0"), append(append([]string{"B0"}, otherKeysB...), "C0")},
+		{"Duplicate keys", []string{"A0", "B0", "A0"}, []string{"B0", "A0", "B0"}},
 	}
 	for _, tt := range tests {
 		mu := sync.Mutex{}
@@ -242,3 +246,440 @@ func TestConcurrencyGroupMultiAvoidDeadlock(t *testing.T) {
 		}
 	}
 }
+
+func TestTryGoKey(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	block := make(chan struct{})
+	if !cg.TryGoKey("samegroup", func() error {
+		<-block
+		return nil
+	}) {
+		t.Fatal("TryGoKey failed with a free key")
+	}
+	// The key lock is taken by the first function without waiting for it to start.
+	if cg.TryGoKey("samegroup", func() error {
+		t.Error("function is called while the key is held")
+		return nil
+	}) {
+		t.Error("TryGoKey succeeded with a held key")
+	}
+	if cg.TryGoMultiKey([]string{"othergroup", "samegroup"}, func() error {
+		t.Error("function is called while the key is held")
+		return nil
+	}) {
+		t.Error("TryGoMultiKey succeeded with a held key")
+	}
+	if !cg.TryGoKey("othergroup", func() error {
+		return nil
+	}) {
+		t.Error("TryGoKey failed with a free key")
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !cg.TryGoKey("samegroup", func() error {
+		return nil
+	}) {
+		t.Error("TryGoKey failed after the key is released")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGoMultiDuplicateKeys(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var got []string
+	cg.OnResult(func(r concgroup.TaskResult) {
+		got = r.Keys
+	})
+	cg.GoMulti([]string{"tenant-b", "tenant-a", "tenant-b"}, func() error {
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"tenant-a", "tenant-b"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCancelKeys(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	ch := cg.Subscribe("tenant-a")
+	started := make(chan struct{})
+	cg.GoCtx("tenant-a", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	called := false
+	cg.Go("tenant-a", func() error {
+		called = true
+		return nil
+	})
+	otherCalled := false
+	cg.Go("tenant-b", func() error {
+		otherCalled = true
+		return nil
+	})
+	cg.CancelKeys("tenant-a")
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if called {
+		t.Error("canceled function is called")
+	}
+	if !otherCalled {
+		t.Error("function with other key is not called")
+	}
+	var errs []error
+	for r := range ch {
+		errs = append(errs, r.Err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d results, want 2", len(errs))
+	}
+	for _, err := range errs {
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, concgroup.ErrCanceled) {
+			t.Errorf("got %v, want canceled", err)
+		}
+	}
+}
+
+func TestCancelMatching(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("shard-0", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	mu := sync.Mutex{}
+	var called []string
+	for _, key := range []string{"shard-0", "shard-1", "tenant-a", "shard-0"} {
+		key := key
+		cg.Go(key, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			called = append(called, key)
+			return nil
+		})
+	}
+	cg.CancelMatching(func(key string) bool {
+		return key == "shard-0"
+	})
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	for _, key := range called {
+		if key == "shard-0" {
+			t.Error("canceled function is called")
+		}
+	}
+	if len(called) != 2 {
+		t.Errorf("got %v, want 2 functions called", called)
+	}
+}
+
+func TestFromErrgroup(t *testing.T) {
+	t.Parallel()
+	eg, ctx := errgroup.WithContext(context.Background())
+	cg := concgroup.FromErrgroup(eg)
+	errTask := errors.New("task error")
+	eg.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+	mu := sync.Mutex{}
+	for i := 0; i < 10; i++ {
+		cg.Go("samegroup", func() error {
+			if !mu.TryLock() {
+				return errors.New("violate group concurrency")
+			}
+			defer mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+	}
+	cg.Go("other", func() error {
+		return errTask
+	})
+	if err := eg.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+}
+
+func TestSkipAfterContextCanceled(t *testing.T) {
+	t.Parallel()
+	cg, _ := concgroup.WithContext(context.Background())
+	ch := cg.Subscribe("samegroup")
+	errTask := errors.New("task error")
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("samegroup", func() error {
+		close(started)
+		<-block
+		return errTask
+	})
+	<-started
+	cg.Go("samegroup", func() error {
+		t.Error("function is called after the context of the group is canceled")
+		return nil
+	})
+	close(block)
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+	var skipped bool
+	for r := range ch {
+		if r.Canceled && errors.Is(r.Err, context.Canceled) {
+			skipped = true
+		}
+	}
+	if !skipped {
+		t.Error("want skipped result")
+	}
+}
+
+func TestCancelWaitingForKeyLock(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cg, _ := concgroup.WithContext(ctx)
+	ch := cg.Subscribe("samegroup")
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("samegroup", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	cg.Go("samegroup", func() error {
+		t.Error("function is called after the context of the group is canceled")
+		return nil
+	})
+	cancel()
+	select {
+	case r := <-ch:
+		if !r.Canceled || !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("got %+v, want canceled", r)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("waiting for the key lock is not canceled")
+	}
+	close(block)
+	if err := cg.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestGoTimeout(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	ch := cg.Subscribe("samegroup")
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("samegroup", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	cg.GoTimeout("samegroup", 50*time.Millisecond, func(ctx context.Context) error {
+		t.Error("function is called after the timeout")
+		return nil
+	})
+	select {
+	case r := <-ch:
+		if !r.Canceled || !errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Errorf("got %+v, want deadline exceeded", r)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("waiting for the key lock is not timed out")
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFIFOPerKey(t *testing.T) {
+	t.Parallel()
+	for _, strategy := range []concgroup.Strategy{concgroup.SortedOrder, concgroup.AllOrNothing, concgroup.WaitDie, concgroup.WoundWait} {
+		cg := new(concgroup.Group)
+		cg.SetStrategy(strategy)
+		var order []int
+		for i := 0; i < 100; i++ {
+			i := i
+			cg.Go("account-1", func() error {
+				order = append(order, i)
+				return nil
+			})
+		}
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		for i, got := range order {
+			if got != i {
+				t.Fatalf("got %v, want in the order of submission", order)
+			}
+		}
+	}
+}
+
+func TestFIFOPerKeyContended(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var mu sync.Mutex
+	var started []uint64
+	cg.AddEventSink(concgroup.EventSinkFunc(func(e concgroup.Event) {
+		if e.Kind == concgroup.EventStarted {
+			mu.Lock()
+			started = append(started, e.Seq)
+			mu.Unlock()
+		}
+	}))
+	var wg sync.WaitGroup
+	for p := 0; p < 16; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				cg.Go("hot", func() error {
+					runtime.Gosched()
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(started); i++ {
+		if started[i] < started[i-1] {
+			t.Fatalf("function %d started after %d, want in the order of submission", started[i-1], started[i])
+		}
+	}
+}
+
+func TestGoCtx(t *testing.T) {
+	t.Parallel()
+	cg, _ := concgroup.WithContext(context.Background())
+	errTask := errors.New("task error")
+	started := make(chan struct{})
+	var passed context.Context
+	cg.GoCtx("tenant-a", func(ctx context.Context) error {
+		passed = ctx
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+	<-started
+	if !cg.TryGoMultiCtx([]string{"tenant-b", "tenant-c"}, func(ctx context.Context) error {
+		return errTask
+	}) {
+		t.Fatal("TryGoMultiCtx failed")
+	}
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+	if passed.Err() == nil {
+		t.Error("context is not canceled")
+	}
+}
+
+func TestWaitContext(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	errTask := errors.New("task error")
+	release := make(chan struct{})
+	cg.Go("tenant-a", func() error {
+		<-release
+		return errTask
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := cg.WaitContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+}
+
+func TestCancelPending(t *testing.T) {
+	t.Parallel()
+	for _, discard := range []bool{false, true} {
+		discard := discard
+		t.Run(fmt.Sprintf("discard=%v", discard), func(t *testing.T) {
+			t.Parallel()
+			cg := new(concgroup.Group)
+			ch := cg.Subscribe("resource-1")
+			var finished int64
+			cg.AddEventSink(concgroup.EventSinkFunc(func(e concgroup.Event) {
+				if e.Kind == concgroup.EventFinished {
+					atomic.AddInt64(&finished, 1)
+				}
+			}))
+			block := make(chan struct{})
+			started := make(chan struct{})
+			cg.GoCtx("resource-1", func(ctx context.Context) error {
+				close(started)
+				<-block
+				return ctx.Err()
+			})
+			<-started
+			for i := 0; i < 2; i++ {
+				cg.Go("resource-1", func() error {
+					t.Error("pending function is called")
+					return nil
+				})
+			}
+			if discard {
+				cg.DiscardPending("resource-1")
+			} else {
+				cg.CancelPending("resource-1")
+			}
+			close(block)
+			if err := cg.Wait(); err != nil {
+				t.Fatal(err)
+			}
+			var results []concgroup.TaskResult
+			for r := range ch {
+				results = append(results, r)
+			}
+			want := 3
+			if discard {
+				want = 1
+			}
+			if len(results) != want {
+				t.Fatalf("got %d results, want %d", len(results), want)
+			}
+			canceled := 0
+			for _, r := range results {
+				if r.Canceled && errors.Is(r.Err, concgroup.ErrCanceled) {
+					canceled++
+				}
+			}
+			if canceled != want-1 {
+				t.Errorf("got %d canceled results, want %d (the running function is not canceled)", canceled, want-1)
+			}
+			if n := atomic.LoadInt64(&finished); n != 3 {
+				t.Errorf("got %d finished events, want 3", n)
+			}
+		})
+	}
+}
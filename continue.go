@@ -0,0 +1,172 @@
+package concgroup
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrSkipped is the error of a function skipped in fail-fast mode because another function has returned an error.
+var ErrSkipped = errors.New("concgroup: skipped after an error")
+
+// ErrKeyFailed is the error of a function canceled in isolate-keys mode because another function with its key has returned an error.
+var ErrKeyFailed = errors.New("concgroup: key failed")
+
+// ErrQuarantined is the error of a function canceled because its key is quarantined after a panic.
+var ErrQuarantined = errors.New("concgroup: key quarantined")
+
+// SetContinueOnError sets whether the group continues on error.
+// In continue-on-error mode, an error of a function does not cancel the context of the group or other functions,
+// and Wait returns the first error after all function calls have returned. With WithContext, the context of the group
+// is still canceled when its parent is, so external signals keep stopping the group. A panic in a function is isolated to
+// its keys: it is reported as a *RecoveredPanic error instead of making Wait panic.
+// It must be called before any function is submitted.
+func (g *Group) SetContinueOnError(on bool) {
+	g.failures.mu.Lock()
+	defer g.failures.mu.Unlock()
+	g.failures.continueOnError = on
+}
+
+// SetCollectErrors sets whether the group collects all errors. In collect-errors mode, the group continues on error
+// like continue-on-error mode, and Wait returns errors.Join of the errors of all functions, each as a *KeyError with its keys.
+// It must be called before any function is submitted.
+func (g *Group) SetCollectErrors(on bool) {
+	g.failures.mu.Lock()
+	defer g.failures.mu.Unlock()
+	g.failures.collect = on
+}
+
+// SetKeyErrors sets whether the error Wait returns for a function is a *KeyError with the keys of the function,
+// so that callers can route the failure back to its tenant or resource. errors.Is and errors.As see through it.
+// The errors of collect-errors and isolate-keys modes are always *KeyError.
+func (g *Group) SetKeyErrors(on bool) {
+	g.failures.mu.Lock()
+	defer g.failures.mu.Unlock()
+	g.failures.wrap = on
+}
+
+// SetErrorHook sets the function applied to the error returned by every function before it is recorded, such as to
+// classify or wrap errors, or to suppress expected ones like context.Canceled during shutdown by returning nil,
+// in which case the function counts as succeeded. hook is called with the keys of the function.
+// Panics and the errors of functions canceled before being called are not passed to hook.
+func (g *Group) SetErrorHook(hook func(keys []string, err error) error) {
+	g.failures.mu.Lock()
+	defer g.failures.mu.Unlock()
+	g.failures.hook = hook
+}
+
+// SetIsolateKeys sets whether errors are isolated per key. In isolate-keys mode, an error of a function cancels only
+// the pending and running functions sharing a key with it, while the functions with other keys keep running.
+// The canceled functions have ErrKeyFailed. Wait returns the errors of all functions like collect-errors mode.
+// It must be called before any function is submitted.
+func (g *Group) SetIsolateKeys(on bool) {
+	g.failures.mu.Lock()
+	defer g.failures.mu.Unlock()
+	g.failures.isolate = on
+}
+
+// SetFailFast sets whether the group fails fast. In fail-fast mode, once a function has returned the error Wait
+// returns, the functions that have not been called yet (such as the ones waiting for key locks) are skipped even
+// without WithContext, and their results have ErrSkipped.
+func (g *Group) SetFailFast(on bool) {
+	g.failFast.Store(on)
+}
+
+// SetQuarantineOnPanic sets whether the keys of a function that panicked are quarantined in continue-on-error mode.
+// The functions with a quarantined key that have not started yet, including the ones submitted later, are not called,
+// and their results have ErrQuarantined.
+func (g *Group) SetQuarantineOnPanic(on bool) {
+	g.failures.mu.Lock()
+	defer g.failures.mu.Unlock()
+	g.failures.quarantine = on
+}
+
+// failures records the errors of functions in continue-on-error mode.
+type failures struct {
+	mu              sync.Mutex
+	continueOnError bool
+	collect         bool
+	isolate         bool
+	quarantine      bool
+	wrap            bool
+	hook            func(keys []string, err error) error
+	first           error
+	errs            []error
+}
+
+func (f *failures) enabled() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.continueOnError || f.collect || f.isolate
+}
+
+func (f *failures) isolateKeys() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.isolate
+}
+
+func (f *failures) quarantineOnPanic() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.quarantine
+}
+
+func (f *failures) add(r TaskResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.collect || f.isolate {
+		f.errs = append(f.errs, &KeyError{Keys: r.Keys, Err: r.Err})
+		return
+	}
+	if f.first == nil {
+		f.first = f.errorOf(r)
+	}
+}
+
+// transform applies the error hook to the error of r.
+func (f *failures) transform(r TaskResult) error {
+	f.mu.Lock()
+	hook := f.hook
+	f.mu.Unlock()
+	if hook == nil {
+		return r.Err
+	}
+	return hook(r.Keys, r.Err)
+}
+
+// keyError returns the error of r for Wait.
+func (f *failures) keyError(r TaskResult) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.errorOf(r)
+}
+
+func (f *failures) errorOf(r TaskResult) error {
+	if f.wrap {
+		return &KeyError{Keys: r.Keys, Err: r.Err}
+	}
+	return r.Err
+}
+
+// take returns the first error, or all the errors joined in collect-errors mode, and clears them.
+func (f *failures) take() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.first
+	if len(f.errs) > 0 {
+		err = errors.Join(f.errs...)
+	}
+	f.first = nil
+	f.errs = nil
+	return err
+}
+
+// sharesKey reports whether a and b have a key in common.
+func sharesKey(a, b []string) bool {
+	for _, k := range a {
+		if contains(b, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetContinueOnError(t *testing.T) {
+	t.Parallel()
+	cg, ctx := concgroup.WithContext(context.Background())
+	cg.SetContinueOnError(true)
+	errTask := errors.New("task error")
+	cg.Go("a", func() error {
+		return errTask
+	})
+	called := false
+	cg.Go("b", func() error {
+		time.Sleep(50 * time.Millisecond)
+		if ctx.Err() != nil {
+			t.Error("context of the group is canceled")
+		}
+		called = true
+		return nil
+	})
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+	if !called {
+		t.Error("function with other key is not called")
+	}
+}
+
+func TestPanicIsolation(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	cg.SetQuarantineOnPanic(true)
+	ch := cg.Subscribe("a")
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("a", func() error {
+		close(started)
+		<-block
+		panic("boom")
+	})
+	<-started
+	cg.Go("a", func() error {
+		t.Error("function with quarantined key is called")
+		return nil
+	})
+	called := false
+	cg.Go("b", func() error {
+		called = true
+		return nil
+	})
+	close(block)
+	err := cg.Wait()
+	var p *concgroup.RecoveredPanic
+	if !errors.As(err, &p) {
+		t.Fatalf("got %v, want *concgroup.RecoveredPanic", err)
+	}
+	if !called {
+		t.Error("function with other key is not called")
+	}
+	var quarantined bool
+	for r := range ch {
+		if errors.Is(r.Err, concgroup.ErrQuarantined) {
+			quarantined = true
+		}
+	}
+	if !quarantined {
+		t.Error("want result with ErrQuarantined")
+	}
+	cg.Go("a", func() error {
+		t.Error("function with quarantined key is called")
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetFailFast(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetFailFast(true)
+	ch := cg.Subscribe("samegroup")
+	errTask := errors.New("task error")
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("samegroup", func() error {
+		close(started)
+		<-block
+		return errTask
+	})
+	<-started
+	for i := 0; i < 3; i++ {
+		cg.Go("samegroup", func() error {
+			t.Error("function is called after an error")
+			return nil
+		})
+	}
+	close(block)
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+	skipped := 0
+	for r := range ch {
+		if errors.Is(r.Err, concgroup.ErrSkipped) {
+			skipped++
+		}
+	}
+	if skipped != 3 {
+		t.Errorf("got %d skipped, want 3", skipped)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetCollectErrors(true)
+	errTask := errors.New("task error")
+	for _, key := range []string{"customer-1", "customer-2", "customer-3"} {
+		key := key
+		cg.Go(key, func() error {
+			if key == "customer-2" {
+				return nil
+			}
+			return fmt.Errorf("%s: %w", key, errTask)
+		})
+	}
+	err := cg.Wait()
+	if !errors.Is(err, errTask) {
+		t.Fatalf("got %v, want %v", err, errTask)
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("got %T, want joined errors", err)
+	}
+	var keys []string
+	for _, err := range joined.Unwrap() {
+		var kerr *concgroup.KeyError
+		if !errors.As(err, &kerr) {
+			t.Fatalf("got %T, want *concgroup.KeyError", err)
+		}
+		keys = append(keys, kerr.Keys...)
+	}
+	sort.Strings(keys)
+	if got, want := fmt.Sprint(keys), "[customer-1 customer-3]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestIsolateKeys(t *testing.T) {
+	t.Parallel()
+	cg, ctx := concgroup.WithContext(context.Background())
+	cg.SetIsolateKeys(true)
+	errTask := errors.New("task error")
+	results := cg.Subscribe("a")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cg.Go("a", func() error {
+		close(started)
+		<-release
+		return errTask
+	})
+	<-started
+	calledA := false
+	cg.Go("a", func() error {
+		calledA = true
+		return nil
+	})
+	calledB := false
+	cg.Go("b", func() error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	cg.Go("b", func() error {
+		calledB = ctx.Err() == nil
+		return nil
+	})
+	close(release)
+	err := cg.Wait()
+	if !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+	var kerr *concgroup.KeyError
+	if !errors.As(err, &kerr) || kerr.Keys[0] != "a" {
+		t.Errorf("got %v, want the error of a", err)
+	}
+	if calledA {
+		t.Error("function with the failed key is called")
+	}
+	var errs []error
+	for r := range results {
+		errs = append(errs, r.Err)
+	}
+	if len(errs) != 2 || !errors.Is(errs[1], concgroup.ErrKeyFailed) {
+		t.Errorf("got %v, want the second canceled with %v", errs, concgroup.ErrKeyFailed)
+	}
+	if !calledB {
+		t.Error("function with another key is not called with the live context")
+	}
+}
+
+func TestSetKeyErrors(t *testing.T) {
+	t.Parallel()
+	errTask := errors.New("task error")
+	for _, continueOnError := range []bool{false, true} {
+		cg := new(concgroup.Group)
+		cg.SetKeyErrors(true)
+		cg.SetContinueOnError(continueOnError)
+		cg.GoMulti([]string{"tenant-b", "tenant-a"}, func() error {
+			return errTask
+		})
+		err := cg.Wait()
+		if !errors.Is(err, errTask) {
+			t.Errorf("got %v, want %v", err, errTask)
+		}
+		var kerr *concgroup.KeyError
+		if !errors.As(err, &kerr) {
+			t.Fatalf("got %T, want *concgroup.KeyError", err)
+		}
+		if got, want := fmt.Sprint(kerr.Keys), "[tenant-a tenant-b]"; got != want {
+			t.Errorf("got keys %s, want %s", got, want)
+		}
+	}
+}
+
+func TestSetErrorHook(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	errTask := errors.New("task error")
+	var hooked []string
+	cg.SetErrorHook(func(keys []string, err error) error {
+		hooked = append(hooked, keys...)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return fmt.Errorf("classified: %w", err)
+	})
+	var failed []concgroup.TaskResult
+	cg.OnError(func(r concgroup.TaskResult) {
+		failed = append(failed, r)
+	})
+	cg.Go("a", func() error {
+		return context.Canceled
+	})
+	if err := cg.Wait(); err != nil {
+		t.Errorf("got %v, want the error suppressed", err)
+	}
+	cg.Go("b", func() error {
+		return errTask
+	})
+	err := cg.Wait()
+	if !errors.Is(err, errTask) || err.Error() != "classified: task error" {
+		t.Errorf("got %v, want the error wrapped", err)
+	}
+	if got, want := fmt.Sprint(hooked), "[a b]"; got != want {
+		t.Errorf("got hooked keys %s, want %s", got, want)
+	}
+	if len(failed) != 1 || failed[0].Err != err {
+		t.Errorf("got %v, want the transformed error passed to OnError", failed)
+	}
+}
+
+func TestSetContinueOnErrorParentCanceled(t *testing.T) {
+	t.Parallel()
+	parent, cancel := context.WithCancel(context.Background())
+	cg, ctx := concgroup.WithContext(parent)
+	cg.SetContinueOnError(true)
+	errTask := errors.New("task error")
+	cg.Go("a", func() error {
+		return errTask
+	})
+	cg.Go("b", func() error {
+		<-ctx.Done()
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Error("context of the group is canceled by the error")
+	}
+	cancel()
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+}
@@ -0,0 +1,118 @@
+package concgroup
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// TaskID identifies a function submitted by GoAfter, for other functions to depend on.
+type TaskID uint64
+
+var (
+	// ErrDependencyFailed is the error of a function not called because a function it depends on failed or was canceled.
+	ErrDependencyFailed = errors.New("concgroup: dependency failed")
+	// ErrUnknownDependency is the error of a function depending on a TaskID not returned by GoAfter of the group.
+	ErrUnknownDependency = errors.New("concgroup: unknown dependency")
+)
+
+// GoAfter calls the given function in a new goroutine like Go once all the functions of dependsOn have succeeded,
+// and returns its ID for other functions to depend on, so that the group can drive a small build or ETL graph.
+// While waiting for the dependencies, the function takes neither its key lock nor a slot of the limit.
+// If any of the dependencies fails or is canceled, the function is not called and is canceled with ErrDependencyFailed.
+// A function can only depend on functions submitted by GoAfter before it, so the dependencies never form a cycle:
+// an ID not returned yet fails the function with ErrUnknownDependency.
+func (g *Group) GoAfter(dependsOn []TaskID, key string, f func() error) TaskID {
+	return g.goAfter(dependsOn, spec{keys: []string{key}}, Adapt(f))
+}
+
+// GoMultiAfter calls the given function like GoAfter with multiple key locks.
+func (g *Group) GoMultiAfter(dependsOn []TaskID, keys []string, f func() error) TaskID {
+	return g.goAfter(dependsOn, spec{keys: sortedKeys(keys)}, Adapt(f))
+}
+
+func (g *Group) goAfter(dependsOn []TaskID, s spec, f func(ctx context.Context) error) TaskID {
+	g.init()
+	id, node, deps, err := g.dag.add(dependsOn)
+	s.done = node.finish
+	if err != nil {
+		g.invalid(s, err)
+		return id
+	}
+	if len(deps) == 0 {
+		g.submit(s, f)
+		return id
+	}
+	g.later(s, f, func() error {
+		for _, d := range deps {
+			select {
+			case <-d.done:
+			case <-g.ctx.Done():
+				// Submit to be canceled like the other functions waiting in the group.
+			}
+			if d.failed() {
+				return ErrDependencyFailed
+			}
+		}
+		return nil
+	})
+	return id
+}
+
+// dag is the functions submitted by GoAfter. The nodes are kept for the lifetime of the group to be depended on.
+type dag struct {
+	mu    sync.Mutex
+	last  TaskID
+	nodes map[TaskID]*dagNode
+}
+
+type dagNode struct {
+	done chan struct{}
+	mu   sync.Mutex
+	ok   bool
+}
+
+// add adds a node depending on the nodes of ids, which must have been added before.
+func (d *dag) add(ids []TaskID) (TaskID, *dagNode, []*dagNode, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var (
+		deps []*dagNode
+		err  error
+	)
+	for _, id := range ids {
+		n, ok := d.nodes[id]
+		if !ok {
+			err = ErrUnknownDependency
+			break
+		}
+		deps = append(deps, n)
+	}
+	d.last++
+	n := &dagNode{done: make(chan struct{})}
+	if d.nodes == nil {
+		d.nodes = map[TaskID]*dagNode{}
+	}
+	d.nodes[d.last] = n
+	return d.last, n, deps, err
+}
+
+// finish records the result of the function of n, releasing the functions depending on it.
+func (n *dagNode) finish(r TaskResult) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ok = r.Err == nil && !r.Canceled
+	close(n.done)
+}
+
+// failed reports whether the function of n has failed or been canceled. It is false while n is not done.
+func (n *dagNode) failed() bool {
+	select {
+	case <-n.done:
+	default:
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return !n.ok
+}
@@ -0,0 +1,85 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestGoAfter(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	// The functions waiting for dependencies do not take the only slot of the limit.
+	cg.SetLimit(1)
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	step := func(name string) func() error {
+		return func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	fetch := cg.GoAfter(nil, "fetch", step("fetch"))
+	build := cg.GoAfter([]concgroup.TaskID{fetch}, "build", step("build"))
+	lint := cg.GoAfter([]concgroup.TaskID{fetch}, "lint", step("lint"))
+	cg.GoMultiAfter([]concgroup.TaskID{lint, build}, []string{"artifact", "report"}, step("release"))
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 4 || order[0] != "fetch" || order[3] != "release" {
+		t.Errorf("got %v, want fetch first and release last", order)
+	}
+}
+
+func TestGoAfterDependencyFailed(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	var (
+		mu      sync.Mutex
+		results = map[string]concgroup.TaskResult{}
+	)
+	cg.OnResult(func(r concgroup.TaskResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[r.Keys[0]] = r
+	})
+	errExtract := errors.New("extract failed")
+	extract := cg.GoAfter(nil, "extract", func() error { return errExtract })
+	transform := cg.GoAfter([]concgroup.TaskID{extract}, "transform", func() error {
+		t.Error("function is called after its dependency failed")
+		return nil
+	})
+	cg.GoAfter([]concgroup.TaskID{transform}, "load", func() error {
+		t.Error("function is called after its dependency failed")
+		return nil
+	})
+	if err := cg.Wait(); !errors.Is(err, errExtract) {
+		t.Errorf("got %v, want %v", err, errExtract)
+	}
+	for _, key := range []string{"transform", "load"} {
+		r := results[key]
+		if !r.Canceled || !errors.Is(r.Err, concgroup.ErrDependencyFailed) {
+			t.Errorf("got %+v of %s, want canceled with %v", r, key, concgroup.ErrDependencyFailed)
+		}
+	}
+}
+
+func TestGoAfterUnknownDependency(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	id := cg.GoAfter(nil, "a", func() error { return nil })
+	cg.GoAfter([]concgroup.TaskID{id + 1}, "b", func() error {
+		t.Error("function with an unknown dependency is called")
+		return nil
+	})
+	if err := cg.Wait(); !errors.Is(err, concgroup.ErrUnknownDependency) {
+		t.Errorf("got %v, want %v", err, concgroup.ErrUnknownDependency)
+	}
+}
@@ -0,0 +1,170 @@
+package concgroup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeadlockError is the error of a function detected by SetDeadlockDetector.
+type DeadlockError struct {
+	// Waits is the functions waiting for each other in a cycle, each waiting for a key held by the next one and the last
+	// for a key held by the first one. It is the single function waiting too long unless Cycle is true.
+	Waits []KeyWait
+	// Cycle reports whether Waits is a cycle of the wait-for graph.
+	Cycle bool
+}
+
+// KeyWait is a function waiting for a key lock.
+type KeyWait struct {
+	// Held is the keys the function holds, including the keys of the function calling LockKey with its context.
+	Held []string
+	// Key is the key the function waits for.
+	Key string
+	// Waited is the time since the submission of the function.
+	Waited time.Duration
+}
+
+func (w KeyWait) String() string {
+	return fmt.Sprintf("holding [%s] waits for %s", strings.Join(w.Held, " "), w.Key)
+}
+
+func (e *DeadlockError) Error() string {
+	if !e.Cycle {
+		return fmt.Sprintf("concgroup: waiting too long: %s for %s", e.Waits[0], e.Waits[0].Waited.Round(time.Millisecond))
+	}
+	waits := make([]string, 0, len(e.Waits))
+	for _, w := range e.Waits {
+		waits = append(waits, w.String())
+	}
+	return "concgroup: deadlock: " + strings.Join(waits, ", ")
+}
+
+// SetDeadlockDetector makes the group detect functions waiting for each other's key locks, which may happen when
+// functions call LockKey with their contexts or take locks outside the group such as by SetLockerFactory, and functions
+// waiting for their key locks longer than timeout since the submission. A zero timeout detects only the cycles.
+// The detection is done with the wait-for graph from the functions waiting for a key lock to the holders of the lock,
+// and from the functions calling LockKey or LockMultiKey with their contexts to the calls.
+// If report is not nil, it is called with the error of each detection, and the functions keep waiting.
+// Otherwise the function completing a cycle or waiting too long fails with the *DeadlockError without being called,
+// which breaks the cycle, and LockKey and LockMultiKey return the error.
+func (g *Group) SetDeadlockDetector(timeout time.Duration, report func(err *DeadlockError)) {
+	g.init()
+	g.locks.mu.Lock()
+	defer g.locks.mu.Unlock()
+	g.locks.detector = &detector{timeout: timeout, report: report}
+}
+
+// detector is the configuration set by SetDeadlockDetector.
+type detector struct {
+	timeout time.Duration
+	report  func(err *DeadlockError)
+}
+
+// taskKey is the context key of the task calling a function.
+type taskKey struct{}
+
+// callerOf returns the task whose function is called with ctx, or nil if none.
+func callerOf(ctx context.Context) *task {
+	t, _ := ctx.Value(taskKey{}).(*task)
+	return t
+}
+
+// detect detects a cycle of the wait-for graph through t, or t waiting too long, if it is enabled.
+// It fails t with the error unless the error is reported, and returns the error to report.
+func (lt *lockTable) detect(t *task) *DeadlockError {
+	d := lt.detector
+	if d == nil || t.waiting == nil {
+		return nil
+	}
+	var err *DeadlockError
+	if !t.deadlocked {
+		if cycle := lt.cycle(t); cycle != nil {
+			err = &DeadlockError{Cycle: true}
+			for _, c := range cycle {
+				c.deadlocked = true
+				if c.waiting != nil {
+					err.Waits = append(err.Waits, c.keyWait())
+				}
+			}
+		}
+	}
+	if err == nil && d.timeout > 0 && !t.overdue && time.Since(t.submitted) >= d.timeout {
+		t.overdue = true
+		err = &DeadlockError{Waits: []KeyWait{t.keyWait()}}
+	}
+	if err == nil || d.report != nil {
+		return err
+	}
+	lt.cancelTask(t, err)
+	return nil
+}
+
+// overdue returns the channel to receive when t waits too long, or nil if it is not to be detected.
+// stop must be called when t stops waiting.
+func (lt *lockTable) overdue(t *task) (c <-chan time.Time, stop func() bool) {
+	d := lt.detector
+	if d == nil || d.timeout <= 0 || t.overdue || t.canceled {
+		return nil, func() bool { return false }
+	}
+	timer := time.NewTimer(time.Until(t.submitted.Add(d.timeout)))
+	return timer.C, timer.Stop
+}
+
+// cycle returns the tasks of a cycle of the wait-for graph through t in order from t, or nil if none.
+func (lt *lockTable) cycle(t *task) []*task {
+	calls := map[*task][]*task{}
+	lt.eachTask(func(o *task) {
+		if o.caller != nil {
+			calls[o.caller] = append(calls[o.caller], o)
+		}
+	})
+	// waitsFor returns the tasks u waits for.
+	waitsFor := func(u *task) []*task {
+		ws := append([]*task{}, calls[u]...)
+		if u.waiting != nil {
+			for h := range u.waiting.holders {
+				if h != u && !h.canceled {
+					ws = append(ws, h)
+				}
+			}
+		}
+		return ws
+	}
+	path := []*task{t}
+	visited := map[*task]bool{t: true}
+	var walk func(u *task) bool
+	walk = func(u *task) bool {
+		for _, w := range waitsFor(u) {
+			if w == t {
+				return true
+			}
+			if visited[w] {
+				continue
+			}
+			visited[w] = true
+			path = append(path, w)
+			if walk(w) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if !walk(t) {
+		return nil
+	}
+	return path
+}
+
+// keyWait returns the wait of t for a key lock.
+func (t *task) keyWait() KeyWait {
+	var held []string
+	for c := t; c != nil; c = c.caller {
+		for _, k := range c.held {
+			held = append(held, k.key)
+		}
+	}
+	return KeyWait{Held: sortedKeys(held), Key: t.waiting.key, Waited: time.Since(t.submitted)}
+}
@@ -0,0 +1,85 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetDeadlockDetectorCycle(t *testing.T) {
+	cg := &concgroup.Group{}
+	cg.SetDeadlockDetector(0, nil)
+	var both sync.WaitGroup
+	both.Add(2)
+	lock := func(key string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			both.Done()
+			both.Wait()
+			unlock, err := cg.LockKey(ctx, key)
+			if err != nil {
+				return err
+			}
+			unlock()
+			return nil
+		}
+	}
+	cg.GoCtx("a", lock("b"))
+	cg.GoCtx("b", lock("a"))
+	err := cg.Wait()
+	var de *concgroup.DeadlockError
+	if !errors.As(err, &de) {
+		t.Fatalf("got %v, want *DeadlockError", err)
+	}
+	if !de.Cycle || len(de.Waits) != 2 {
+		t.Fatalf("got %#v, want a cycle of 2 waits", de)
+	}
+	for _, w := range de.Waits {
+		if len(w.Held) != 1 || w.Held[0] == w.Key {
+			t.Errorf("got %v, want holding one key and waiting for the other", w)
+		}
+	}
+}
+
+func TestSetDeadlockDetectorTimeout(t *testing.T) {
+	cg := &concgroup.Group{}
+	var mu sync.Mutex
+	var reported []*concgroup.DeadlockError
+	cg.SetDeadlockDetector(20*time.Millisecond, func(err *concgroup.DeadlockError) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	})
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("key", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	called := false
+	cg.Go("key", func() error {
+		called = true
+		return nil
+	})
+	time.Sleep(100 * time.Millisecond)
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("the reported function was not called")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reported))
+	}
+	if got := reported[0]; got.Cycle || got.Waits[0].Key != "key" || got.Waits[0].Waited < 20*time.Millisecond {
+		t.Errorf("got %#v, want a wait for key longer than the timeout", got)
+	}
+}
@@ -0,0 +1,106 @@
+package concgroup
+
+import (
+	"sync"
+	"time"
+)
+
+// GoOnce calls the given function in a new goroutine like Go unless a function with the same key and id has been
+// submitted by GoOnce within the window set by SetDedupWindow, so that redeliveries of a message by retrying producers
+// such as webhooks run at most once. id is an idempotency token of the message. It reports whether f is submitted.
+// Duplicates are dropped whether the first function is still running, has returned an error or has not been called.
+// A function rejected at submission, for example because the group is closed, does not record its id, so that a retry
+// of it is not dropped.
+func (g *Group) GoOnce(key, id string, f func() error) bool {
+	if !g.dedup.first(key, id) {
+		return false
+	}
+	if !g.submit(spec{keys: []string{key}}, Adapt(f)) {
+		g.dedup.forget(key, id)
+		return false
+	}
+	return true
+}
+
+// defaultDedupWindow is the window of GoOnce unless SetDedupWindow is called, long enough for the retries of the
+// usual webhook producers.
+const defaultDedupWindow = 24 * time.Hour
+
+// SetDedupWindow sets the time from the submission of a function by GoOnce during which the functions with the same
+// key and id are dropped. Zero, the default, is 24 hours. A negative d keeps the ids for the lifetime of the group,
+// so the memory grows with the number of ids. It must be called before GoOnce.
+func (g *Group) SetDedupWindow(d time.Duration) {
+	g.dedup.mu.Lock()
+	defer g.dedup.mu.Unlock()
+	g.dedup.window = d
+}
+
+// dedup is the ids of the functions submitted by GoOnce.
+type dedup struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[dedupKey]time.Time
+	// order is the keys of seen in the order of submission, to expire them from the front.
+	// An entry whose key has been forgotten or recorded again since is skipped.
+	order []dedupEntry
+}
+
+type dedupKey struct {
+	key, id string
+}
+
+type dedupEntry struct {
+	k  dedupKey
+	at time.Time
+}
+
+// first records key and id, and reports whether they are not seen within the window.
+func (d *dedup) first(key, id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	d.expire(now)
+	k := dedupKey{key: key, id: id}
+	if _, ok := d.seen[k]; ok {
+		return false
+	}
+	if d.seen == nil {
+		d.seen = map[dedupKey]time.Time{}
+	}
+	d.seen[k] = now
+	if d.window >= 0 {
+		d.order = append(d.order, dedupEntry{k: k, at: now})
+	}
+	return true
+}
+
+// forget removes key and id recorded by first.
+func (d *dedup) forget(key, id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, dedupKey{key: key, id: id})
+}
+
+// expire forgets the ids submitted before the window.
+func (d *dedup) expire(now time.Time) {
+	window := d.window
+	if window < 0 {
+		return
+	}
+	if window == 0 {
+		window = defaultDedupWindow
+	}
+	n := 0
+	for _, e := range d.order {
+		if now.Sub(e.at) < window {
+			break
+		}
+		if at, ok := d.seen[e.k]; ok && at.Equal(e.at) {
+			delete(d.seen, e.k)
+		}
+		n++
+	}
+	if n > 0 {
+		d.order = append(d.order[:0], d.order[n:]...)
+	}
+}
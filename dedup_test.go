@@ -0,0 +1,79 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestGoOnce(t *testing.T) {
+	cg := &concgroup.Group{}
+	var calls atomic.Int32
+	f := func() error {
+		calls.Add(1)
+		return nil
+	}
+	if !cg.GoOnce("key", "delivery-1", f) {
+		t.Error("the first submission was dropped")
+	}
+	if cg.GoOnce("key", "delivery-1", f) {
+		t.Error("the duplicate was submitted")
+	}
+	if !cg.GoOnce("other", "delivery-1", f) {
+		t.Error("the same id with another key was dropped")
+	}
+	if !cg.GoOnce("key", "delivery-2", f) {
+		t.Error("another id was dropped")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("got %d calls, want %d", got, 3)
+	}
+}
+
+func TestSetDedupWindow(t *testing.T) {
+	cg := &concgroup.Group{}
+	cg.SetDedupWindow(20 * time.Millisecond)
+	f := func() error {
+		return nil
+	}
+	if !cg.GoOnce("key", "id", f) {
+		t.Error("the first submission was dropped")
+	}
+	if cg.GoOnce("key", "id", f) {
+		t.Error("the duplicate within the window was submitted")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !cg.GoOnce("key", "id", f) {
+		t.Error("the submission after the window was dropped")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGoOnceRejected(t *testing.T) {
+	cg := &concgroup.Group{}
+	cg.Close()
+	f := func() error {
+		return nil
+	}
+	if cg.GoOnce("key", "id", f) {
+		t.Error("the submission to the closed group was accepted")
+	}
+	if err := cg.Wait(); err != nil && !errors.Is(err, concgroup.ErrGroupClosed) {
+		t.Fatal(err)
+	}
+	cg.Reset()
+	if !cg.GoOnce("key", "id", f) {
+		t.Error("the retry of the rejected submission was dropped")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,50 @@
+package concgroup
+
+import (
+	"context"
+	"time"
+)
+
+// GoAfterDelay calls the given function in a new goroutine like Go after d elapses.
+// The function takes neither its key lock nor a slot of the limit until then, and waits for them like Go after that.
+// If the context of the group is done first, the function is canceled without waiting for d.
+func (g *Group) GoAfterDelay(key string, d time.Duration, f func() error) {
+	g.GoAt(key, time.Now().Add(d), f)
+}
+
+// GoAt calls the given function in a new goroutine like GoAfterDelay at t.
+func (g *Group) GoAt(key string, t time.Time, f func() error) {
+	g.later(spec{keys: []string{key}}, Adapt(f), func() error {
+		timer := time.NewTimer(time.Until(t))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-g.ctx.Done():
+			// Submit to be canceled like the other functions waiting in the group.
+		}
+		return nil
+	})
+}
+
+// later submits f with s in a new goroutine after wait returns, counting it as a function of the group meanwhile,
+// so that Wait waits for it and closing the group does not reject it. If wait returns an error,
+// f is not called and is canceled with the error.
+func (g *Group) later(s spec, f func(ctx context.Context) error, wait func() error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	g.init()
+	if g.isClosed() {
+		g.reject(s)
+		return
+	}
+	s.deferred = true
+	g.eg.Go(func() error {
+		if err := wait(); err != nil {
+			g.emit(Event{Kind: EventSubmitted, Keys: s.keys})
+			g.finish(&s, 0, TaskResult{Keys: s.keys, Err: err, Canceled: true})
+			return nil
+		}
+		g.submit(s, f)
+		return nil
+	})
+}
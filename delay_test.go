@@ -0,0 +1,65 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestGoAfterDelay(t *testing.T) {
+	t.Parallel()
+	const delay = 50 * time.Millisecond
+	cg := new(concgroup.Group)
+	// The delayed function does not take the only slot of the limit while waiting.
+	cg.SetLimit(1)
+	start := time.Now()
+	var delayedAt, otherAt time.Time
+	cg.GoAfterDelay("samegroup", delay, func() error {
+		delayedAt = time.Now()
+		return nil
+	})
+	cg.Go("othergroup", func() error {
+		otherAt = time.Now()
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := delayedAt.Sub(start); got < delay {
+		t.Errorf("got the delayed function called after %v, want at least %v", got, delay)
+	}
+	if !otherAt.Before(delayedAt) {
+		t.Error("function without delay is not called before the delayed function")
+	}
+}
+
+func TestGoAtCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cg, _ := concgroup.WithContext(ctx)
+	ch := cg.Subscribe("samegroup")
+	cg.GoAt("samegroup", time.Now().Add(time.Hour), func() error {
+		t.Error("function is called after the context of the group is canceled")
+		return nil
+	})
+	cancel()
+	done := make(chan error)
+	go func() {
+		done <- cg.Wait()
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait does not return after the context of the group is canceled")
+	}
+	r := <-ch
+	if !r.Canceled || !errors.Is(r.Err, context.Canceled) {
+		t.Errorf("got %+v, want canceled", r)
+	}
+}
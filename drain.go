@@ -0,0 +1,91 @@
+package concgroup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrGroupClosed is the error of a function submitted after the group is closed by Close or Drain.
+var ErrGroupClosed = errors.New("concgroup: group closed")
+
+// SetDrainRate limits how fast the remaining functions start while the group is draining to at most r per second,
+// so that the final flush does not spike load on backends. A zero or negative value indicates no limit.
+func (g *Group) SetDrainRate(r float64) {
+	g.pacer.setRate(r)
+}
+
+// Drain closes the group and blocks until all function calls have returned or ctx is done.
+// Functions submitted after Drain is called are not called, and their results have ErrGroupClosed.
+// It returns the error Wait returns, or the error of ctx if it is done first.
+func (g *Group) Drain(ctx context.Context) error {
+	g.mu.Lock()
+	g.init()
+	g.close()
+	g.mu.Unlock()
+	g.pacer.start()
+	return g.WaitContext(ctx)
+}
+
+// Shutdown drains the group like Drain, for a clean shutdown of a service. If ctx is done before all function calls
+// have returned, it cancels all the functions: the waiting ones return without being called, and the context passed
+// to the running ones is canceled. Then it returns the sorted keys of the abandoned functions and the error of ctx
+// without waiting for them further, as functions ignoring their context could block forever.
+func (g *Group) Shutdown(ctx context.Context) ([]string, error) {
+	err := g.Drain(ctx)
+	if ctx.Err() == nil || err != ctx.Err() {
+		return nil, err
+	}
+	return g.locks.abandon(g), err
+}
+
+// pacer paces the start of functions while the group is draining.
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	// draining is set by Drain and cleared by Reset.
+	draining bool
+	next     time.Time
+}
+
+func (p *pacer) setRate(r float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if r <= 0 {
+		p.interval = 0
+		return
+	}
+	p.interval = time.Duration(float64(time.Second) / r)
+}
+
+func (p *pacer) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = true
+}
+
+// reset stops pacing the start of functions until the group drains again.
+func (p *pacer) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = false
+	p.next = time.Time{}
+}
+
+// wait blocks until the next function can start.
+func (p *pacer) wait() {
+	p.mu.Lock()
+	if !p.draining || p.interval == 0 {
+		p.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(p.interval)
+	p.mu.Unlock()
+	time.Sleep(time.Until(at))
+}
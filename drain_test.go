@@ -0,0 +1,183 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	ch := cg.Subscribe("late")
+	cg.Go("samegroup", func() error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cg.Go("late", func() error {
+			t.Error("function submitted after Drain is called")
+			return nil
+		})
+	}()
+	if err := cg.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	r, ok := <-ch
+	if !ok {
+		t.Fatal("want result")
+	}
+	if !errors.Is(r.Err, concgroup.ErrGroupClosed) {
+		t.Errorf("got %v, want %v", r.Err, concgroup.ErrGroupClosed)
+	}
+}
+
+func TestDrainDeadline(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.Go("samegroup", func() error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := cg.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	started := make(chan struct{}, 2)
+	canceled := make(chan struct{})
+	cg.GoCtx("worker", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+	block := make(chan struct{})
+	cg.Go("stuck", func() error {
+		started <- struct{}{}
+		<-block
+		return nil
+	})
+	<-started
+	<-started
+	cg.Go("worker", func() error {
+		t.Error("pending function is called after shutdown")
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	abandoned, err := cg.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got, want := strings.Join(abandoned, ","), "stuck,worker"; got != want {
+		t.Errorf("got abandoned keys %s, want %s", got, want)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Error("the context of the running function is not canceled")
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShutdownCompleted(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.Go("worker", func() error { return nil })
+	abandoned, err := cg.Shutdown(context.Background())
+	if err != nil {
+		t.Error(err)
+	}
+	if len(abandoned) != 0 {
+		t.Errorf("got abandoned keys %v, want none", abandoned)
+	}
+}
+
+func TestSetDrainRate(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetDrainRate(20)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("samegroup", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	const remaining = 5
+	for i := 0; i < remaining; i++ {
+		cg.Go("samegroup", func() error {
+			return nil
+		})
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(block)
+	}()
+	start := time.Now()
+	if err := cg.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < (remaining-1)*50*time.Millisecond {
+		t.Errorf("got %v, want remaining functions to start at most 20 per second", elapsed)
+	}
+}
+
+func TestSetDrainRateReset(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetDrainRate(20)
+	run := func(drain bool) time.Duration {
+		block := make(chan struct{})
+		started := make(chan struct{})
+		cg.Go("samegroup", func() error {
+			close(started)
+			<-block
+			return nil
+		})
+		<-started
+		for i := 0; i < 5; i++ {
+			cg.Go("samegroup", func() error { return nil })
+		}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			close(block)
+		}()
+		start := time.Now()
+		if drain {
+			if err := cg.Drain(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		return time.Since(start)
+	}
+	run(true)
+	cg.Reset()
+	if elapsed := run(false); elapsed >= 100*time.Millisecond {
+		t.Errorf("got %v, want the functions after Reset not paced", elapsed)
+	}
+	cg.Reset()
+	if elapsed := run(true); elapsed < 4*50*time.Millisecond {
+		t.Errorf("got %v, want the functions paced again by another Drain", elapsed)
+	}
+}
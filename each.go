@@ -0,0 +1,42 @@
+package concgroup
+
+import (
+	"sort"
+)
+
+// GoEach calls task for each item of items in a new goroutine with the key lock of key(item) like Go,
+// so that the items with the same key are processed sequentially in the order of items.
+//
+//	concgroup.GoEach(cg, urls, keyfuncs.Host, func(url string) error { ... })
+func GoEach[T any](g *Group, items []T, key func(item T) string, task func(item T) error) {
+	for _, item := range items {
+		item := item
+		g.Go(key(item), func() error {
+			return task(item)
+		})
+	}
+}
+
+// GoMapKeys calls task for each value of m in a new goroutine with the key lock of its map key like Go,
+// so that the values of a map key are processed sequentially in the order of the slice.
+// The map keys are submitted in sorted order.
+//
+//	concgroup.GoMapKeys(cg, urlgroups, func(key, url string) error { ... })
+func GoMapKeys[K ~string, V any](g *Group, m map[K][]V, task func(key K, value V) error) {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		key := key
+		for _, value := range m[key] {
+			value := value
+			g.Go(string(key), func() error {
+				return task(key, value)
+			})
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func ExampleGoMapKeys() {
+	cg := new(concgroup.Group)
+	var urlgroups = map[string][]string{
+		"go": {
+			"https://go.dev/",
+			"https://go.dev/dl/",
+		},
+		"google": {
+			"http://www.google.com/",
+		},
+	}
+	concgroup.GoMapKeys(cg, urlgroups, func(_, url string) error {
+		// Fetch URL sequentially by key
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	})
+	// Wait for all HTTP fetches to complete.
+	if err := cg.Wait(); err == nil {
+		fmt.Println("Successfully fetched all URLs.")
+	}
+}
+
+func TestGoEach(t *testing.T) {
+	cg := &concgroup.Group{}
+	var mu sync.Mutex
+	got := map[string][]string{}
+	items := []string{"a/1", "b/1", "a/2", "a/3", "b/2"}
+	concgroup.GoEach(cg, items, func(item string) string {
+		return strings.Split(item, "/")[0]
+	}, func(item string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.Split(item, "/")[0]
+		got[key] = append(got[key], item)
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"a": {"a/1", "a/2", "a/3"}, "b": {"b/1", "b/2"}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGoMapKeys(t *testing.T) {
+	type tenant string
+	cg := &concgroup.Group{}
+	var mu sync.Mutex
+	got := map[tenant][]int{}
+	concgroup.GoMapKeys(cg, map[tenant][]int{"a": {1, 2, 3}, "b": {4, 5}}, func(key tenant, v int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got[key] = append(got[key], v)
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[tenant][]int{"a": {1, 2, 3}, "b": {4, 5}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
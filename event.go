@@ -0,0 +1,103 @@
+package concgroup
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	// EventSubmitted is the event of a function submitted to the group.
+	EventSubmitted EventKind = iota
+	// EventStarted is the event of a function called after acquiring the key locks.
+	EventStarted
+	// EventFinished is the event of a function returned or canceled.
+	EventFinished
+)
+
+// String returns the name of k.
+func (k EventKind) String() string {
+	switch k {
+	case EventSubmitted:
+		return "submitted"
+	case EventStarted:
+		return "started"
+	case EventFinished:
+		return "finished"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is an event of the lifecycle of a function in the group.
+type Event struct {
+	// Kind is the kind of the event.
+	Kind EventKind
+	// Time is the time of the event.
+	Time time.Time
+	// Keys are the keys of the function.
+	Keys []string
+	// Seq is the sequence number of the submission of the function to the group, starting from 1.
+	// It is zero for a function rejected at submission.
+	Seq uint64
+	// Result is the result of the function. It is set only for EventFinished.
+	Result TaskResult
+}
+
+// EventSink receives the events of the group, such as a logger, a metrics exporter or an audit trail.
+type EventSink interface {
+	// Record records e. It is called synchronously by the goroutine of the function, so it should return quickly.
+	Record(e Event)
+}
+
+// EventSinkFunc is a function implementing EventSink.
+type EventSinkFunc func(e Event)
+
+// Record calls f(e).
+func (f EventSinkFunc) Record(e Event) {
+	f(e)
+}
+
+// AddEventSink registers s to receive all the events of the group, in addition to the sinks already registered.
+func (g *Group) AddEventSink(s EventSink) {
+	g.sinks.add(s)
+}
+
+// sinks fans out events to the registered EventSinks.
+type sinks struct {
+	mu    sync.Mutex
+	sinks []EventSink
+}
+
+func (s *sinks) add(sink EventSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sinks = append(s.sinks, sink)
+}
+
+func (s *sinks) record(e Event) {
+	s.mu.Lock()
+	sinks := s.sinks
+	s.mu.Unlock()
+	for _, sink := range sinks {
+		sink.Record(e)
+	}
+}
+
+// emit feeds e to the statistics, the subscriptions and the registered sinks.
+func (g *Group) emit(e Event) {
+	e.Time = time.Now()
+	switch e.Kind {
+	case EventSubmitted:
+		g.stats.submit()
+	case EventStarted:
+		g.stats.start()
+	case EventFinished:
+		g.stats.finish(e.Result)
+		g.subs.publish(e.Result)
+	}
+	g.progress.record(e, &g.subs)
+	g.sinks.record(e)
+}
@@ -0,0 +1,54 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []concgroup.Event
+}
+
+func (r *recorder) Record(e concgroup.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestEventSinks(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	sinks := []*recorder{{}, {}}
+	for _, s := range sinks {
+		cg.AddEventSink(s)
+	}
+	errTask := errors.New("task error")
+	cg.Go("a", func() error { return errTask })
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Fatalf("got %v, want %v", err, errTask)
+	}
+	for _, s := range sinks {
+		want := []concgroup.EventKind{concgroup.EventSubmitted, concgroup.EventStarted, concgroup.EventFinished}
+		if len(s.events) != len(want) {
+			t.Fatalf("got %d events, want %d", len(s.events), len(want))
+		}
+		for i, e := range s.events {
+			if e.Kind != want[i] {
+				t.Errorf("got %v, want %v", e.Kind, want[i])
+			}
+			if len(e.Keys) != 1 || e.Keys[0] != "a" {
+				t.Errorf("got keys %v, want [a]", e.Keys)
+			}
+			if e.Time.IsZero() {
+				t.Error("event time is zero")
+			}
+		}
+		if r := s.events[2].Result; !errors.Is(r.Err, errTask) {
+			t.Errorf("got %v, want %v", r.Err, errTask)
+		}
+	}
+}
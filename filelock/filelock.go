@@ -0,0 +1,111 @@
+// Package filelock provides a concgroup.KeyLocker over file locks, so that the functions of groups in processes
+// on the same host with the same keys do not run at the same time.
+// A key is locked with flock (LockFileEx on Windows) on a file named after the key in a directory shared by the processes.
+//
+//	cg.SetKeyLocker(filelock.New("/var/run/myapp/locks"))
+package filelock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultRetryInterval is the default interval to retry acquiring locks held by others.
+const DefaultRetryInterval = 10 * time.Millisecond
+
+// maxNameLen is the maximum length of the sanitized key in the name of a lock file.
+const maxNameLen = 100
+
+// errUnsupported is returned by Acquire on the platforms without file locks.
+var errUnsupported = errors.New("filelock: file locks are not supported on this platform")
+
+// Locker is a concgroup.KeyLocker over file locks in a directory.
+type Locker struct {
+	dir           string
+	retryInterval time.Duration
+}
+
+// New returns a new Locker with the lock files in dir, which is created if it does not exist.
+// The lock files are kept after they are unlocked, since removing them would race with the processes opening them.
+func New(dir string) *Locker {
+	return &Locker{dir: dir, retryInterval: DefaultRetryInterval}
+}
+
+// SetRetryInterval sets the interval to retry acquiring locks held by others. The default is DefaultRetryInterval.
+// The settings must be made before the locker is used.
+func (l *Locker) SetRetryInterval(d time.Duration) {
+	l.retryInterval = d
+}
+
+// Path returns the path of the lock file of key: the key with the characters other than ASCII letters, digits, '-', '.'
+// and '_' replaced by '_', followed by a hash of the key to keep the keys sanitized to the same name apart.
+func (l *Locker) Path(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		}
+		return '_'
+	}, key)
+	if len(name) > maxNameLen {
+		name = name[:maxNameLen]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return filepath.Join(l.dir, fmt.Sprintf("%s-%08x.lock", name, h.Sum32()))
+}
+
+// Acquire locks the files of keys in order, retrying until they are free or ctx is done.
+func (l *Locker) Acquire(ctx context.Context, keys []string) (func(), error) {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return nil, err
+	}
+	files := make([]*os.File, 0, len(keys))
+	release := func() {
+		for i := len(files) - 1; i >= 0; i-- {
+			_ = unlock(files[i])
+			_ = files[i].Close()
+		}
+	}
+	for _, key := range keys {
+		f, err := l.lock(ctx, l.Path(key))
+		if err != nil {
+			release()
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return release, nil
+}
+
+// lock opens and locks the file of path.
+func (l *Locker) lock(ctx context.Context, path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if ok {
+			return f, nil
+		}
+		timer := time.NewTimer(l.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			_ = f.Close()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package filelock
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestLocker(t *testing.T) {
+	dir := t.TempDir()
+	// Two lockers open the files separately like two processes, and flock locks of separate opens exclude each other.
+	var running atomic.Int32
+	var overlapped atomic.Bool
+	var groups []*concgroup.Group
+	for i := 0; i < 2; i++ {
+		l := New(dir)
+		l.SetRetryInterval(time.Millisecond)
+		cg := &concgroup.Group{}
+		cg.SetKeyLocker(l)
+		for j := 0; j < 5; j++ {
+			cg.GoMulti([]string{"tenant/a", "job:1"}, func() error {
+				if running.Add(1) > 1 {
+					overlapped.Store(true)
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+		}
+		groups = append(groups, cg)
+	}
+	for _, cg := range groups {
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if overlapped.Load() {
+		t.Error("functions with the same keys ran at the same time across lockers")
+	}
+}
+
+func TestLockerCancel(t *testing.T) {
+	dir := t.TempDir()
+	release, err := New(dir).Acquire(context.Background(), []string{"key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := New(dir).Acquire(ctx, []string{"key"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPath(t *testing.T) {
+	l := New("locks")
+	a, b := l.Path("tenant/a"), l.Path("tenant:a")
+	if a == b {
+		t.Errorf("got the same path %s for different keys", a)
+	}
+	if !strings.HasPrefix(a, filepath.Join("locks", "tenant_a-")) || !strings.HasSuffix(a, ".lock") {
+		t.Errorf("got %s", a)
+	}
+	if got := l.Path(strings.Repeat("x", 1000)); len(filepath.Base(got)) > maxNameLen+len("-00000000.lock") {
+		t.Errorf("got %d bytes, want the name truncated", len(got))
+	}
+}
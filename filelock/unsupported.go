@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package filelock
+
+import (
+	"os"
+)
+
+func tryLock(*os.File) (bool, error) {
+	return false, errUnsupported
+}
+
+func unlock(*os.File) error {
+	return errUnsupported
+}
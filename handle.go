@@ -0,0 +1,74 @@
+package concgroup
+
+import (
+	"context"
+)
+
+// Task is a handle of a function submitted by GoHandle or GoMultiHandle, to await or cancel the function alone.
+type Task struct {
+	g      *Group
+	done   chan struct{}
+	result TaskResult
+}
+
+// GoHandle calls the given function in a new goroutine like Go, and returns its handle.
+func (g *Group) GoHandle(key string, f func() error) *Task {
+	return g.goHandle(spec{keys: []string{key}}, Adapt(f))
+}
+
+// GoMultiHandle calls the given function in a new goroutine like GoMulti, and returns its handle.
+func (g *Group) GoMultiHandle(keys []string, f func() error) *Task {
+	return g.goHandle(spec{keys: sortedKeys(keys)}, Adapt(f))
+}
+
+// GoHandleCtx calls the given function in a new goroutine like GoCtx, and returns its handle.
+func (g *Group) GoHandleCtx(key string, f func(ctx context.Context) error) *Task {
+	return g.goHandle(spec{keys: []string{key}}, f)
+}
+
+func (g *Group) goHandle(s spec, f func(ctx context.Context) error) *Task {
+	t := &Task{g: g, done: make(chan struct{})}
+	s.handle = t
+	s.done = t.finish
+	g.submit(s, f)
+	return t
+}
+
+// Done returns a channel closed when the function has returned, or has been canceled or rejected without being called.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns the error of the function once Done is closed, and nil before that.
+func (t *Task) Err() error {
+	select {
+	case <-t.done:
+		return t.result.Err
+	default:
+		return nil
+	}
+}
+
+// Result returns the result of the function once Done is closed, and the zero TaskResult before that.
+func (t *Task) Result() TaskResult {
+	select {
+	case <-t.done:
+		return t.result
+	default:
+		return TaskResult{}
+	}
+}
+
+// Cancel cancels the function like CancelKeys: if it is waiting, it is not called and its result has ErrCanceled,
+// and if it is running, its context is canceled. It does nothing once the function has finished.
+func (t *Task) Cancel() {
+	t.g.init()
+	t.g.locks.cancel(t.g, func(o *task) bool {
+		return o.handle == t
+	}, ErrCanceled)
+}
+
+func (t *Task) finish(r TaskResult) {
+	t.result = r
+	close(t.done)
+}
@@ -0,0 +1,74 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestGoHandle(t *testing.T) {
+	cg := &concgroup.Group{}
+	failed := errors.New("failed")
+	block := make(chan struct{})
+	first := cg.GoHandle("key", func() error {
+		<-block
+		return failed
+	})
+	second := cg.GoMultiHandle([]string{"key", "other"}, func() error {
+		return nil
+	})
+	if err := first.Err(); err != nil {
+		t.Errorf("got %v before done, want nil", err)
+	}
+	close(block)
+	<-first.Done()
+	if err := first.Err(); !errors.Is(err, failed) {
+		t.Errorf("got %v, want %v", err, failed)
+	}
+	<-second.Done()
+	if err := second.Err(); err != nil {
+		t.Error(err)
+	}
+	if got := second.Result().Keys; len(got) != 2 {
+		t.Errorf("got %v, want 2 keys", got)
+	}
+	if err := cg.Wait(); !errors.Is(err, failed) {
+		t.Errorf("got %v, want %v", err, failed)
+	}
+}
+
+func TestTaskCancel(t *testing.T) {
+	cg := &concgroup.Group{}
+	block := make(chan struct{})
+	running := cg.GoHandleCtx("key", func(ctx context.Context) error {
+		close(block)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-block
+	called := false
+	waiting := cg.GoHandle("key", func() error {
+		called = true
+		return nil
+	})
+	other := cg.GoHandle("key", func() error {
+		return nil
+	})
+	waiting.Cancel()
+	running.Cancel()
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(waiting.Err(), concgroup.ErrCanceled) || called {
+		t.Errorf("got %v, want the waiting function canceled", waiting.Err())
+	}
+	if !running.Result().Canceled {
+		t.Error("the running function was not canceled")
+	}
+	if err := other.Err(); err != nil {
+		t.Errorf("got %v, want the other function called", err)
+	}
+	running.Cancel()
+}
@@ -0,0 +1,89 @@
+package concgroup
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// histogramSubBits is the number of significant bits of the values recorded in a histogram.
+// Values are recorded with a relative error of at most 1/2^(histogramSubBits-1).
+const histogramSubBits = 6
+
+const (
+	histogramSubCount = 1 << histogramSubBits
+	histogramHalf     = histogramSubCount / 2
+)
+
+// histogram is a log-linear histogram of durations like HDR Histogram.
+// It keeps only the buckets from the smallest to the largest recorded durations,
+// so that a key with durations of similar magnitude takes a few buckets.
+type histogram struct {
+	// counts is the counts of the buckets from the index offset.
+	counts []uint64
+	offset int
+	total  uint64
+}
+
+func (h *histogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := histogramIndex(uint64(d))
+	switch {
+	case len(h.counts) == 0:
+		h.offset = i
+	case i < h.offset:
+		counts := make([]uint64, h.offset-i+len(h.counts))
+		copy(counts[h.offset-i:], h.counts)
+		h.counts, h.offset = counts, i
+	}
+	for len(h.counts) <= i-h.offset {
+		h.counts = append(h.counts, 0)
+	}
+	h.counts[i-h.offset]++
+	h.total++
+}
+
+// quantile returns the q-quantile (0 <= q <= 1) of the recorded durations.
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+	i := len(h.counts) - 1
+	var n uint64
+	for j, c := range h.counts {
+		n += c
+		if n >= rank {
+			i = j
+			break
+		}
+	}
+	v := histogramValue(h.offset + i)
+	if v > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(v)
+}
+
+func histogramIndex(v uint64) int {
+	if v < histogramSubCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histogramSubBits
+	return (shift+1)*histogramHalf + int(v>>shift) - histogramHalf
+}
+
+// histogramValue returns the middle of the range of values recorded at index i.
+func histogramValue(i int) uint64 {
+	if i < histogramSubCount {
+		return uint64(i)
+	}
+	shift := i/histogramHalf - 1
+	sub := uint64(i%histogramHalf + histogramHalf)
+	return sub<<shift + (1<<shift)/2
+}
@@ -0,0 +1,30 @@
+package concgroup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, d := range []time.Duration{2 * time.Second, time.Second, 3 * time.Second, time.Second} {
+		h.record(d)
+	}
+	if n := len(h.counts); n > 64 {
+		t.Errorf("got %d buckets for durations within 1s to 3s, want at most 64", n)
+	}
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.25, time.Second},
+		{0.75, 2 * time.Second},
+		{1, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		got := h.quantile(tt.q)
+		if diff := got - tt.want; diff < -tt.want/32 || diff > tt.want/32 {
+			t.Errorf("quantile(%v) = %v, want about %v", tt.q, got, tt.want)
+		}
+	}
+}
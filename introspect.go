@@ -0,0 +1,67 @@
+package concgroup
+
+import "sort"
+
+// ActiveKeys returns the sorted keys locked by the functions running now.
+func (g *Group) ActiveKeys() []string {
+	g.init()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	var keys []string
+	for i := range lt.stripes {
+		for key := range lt.stripes[i].running {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PendingCount returns the number of functions with key submitted but not called yet.
+func (g *Group) PendingCount(key string) int {
+	g.init()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return lt.refs(key) - lt.stripe(key).running[key]
+}
+
+// Running returns the number of functions running now.
+func (g *Group) Running() int {
+	g.init()
+	return int(g.locks.runningTasks.Load())
+}
+
+// KeyBusy reports whether a function with key is running or waiting to be called now,
+// so that a function submitted with key now would wait for it.
+func (g *Group) KeyBusy(key string) bool {
+	g.init()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return lt.referred([]string{key})
+}
+
+// IdleKeys returns the sorted keys the functions of the group have finished with that no function is running or
+// waiting for now. The keys are the ones of Stats, so only the most recently used keys as set by SetKeyCacheSize.
+func (g *Group) IdleKeys() []string {
+	g.init()
+	g.stats.mu.Lock()
+	keys := make([]string, 0, g.stats.keys.len())
+	g.stats.keys.each(func(key string, _ *keyStats) {
+		keys = append(keys, key)
+	})
+	g.stats.mu.Unlock()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	idle := keys[:0]
+	for _, key := range keys {
+		if !lt.referred([]string{key}) {
+			idle = append(idle, key)
+		}
+	}
+	sort.Strings(idle)
+	return idle
+}
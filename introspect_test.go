@@ -0,0 +1,87 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestIntrospection(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	block := make(chan struct{})
+	started := make(chan struct{}, 2)
+	for _, key := range []string{"tenant-b", "tenant-a"} {
+		cg.Go(key, func() error {
+			started <- struct{}{}
+			<-block
+			return nil
+		})
+	}
+	<-started
+	<-started
+	for i := 0; i < 3; i++ {
+		cg.Go("tenant-a", func() error { return nil })
+	}
+	if got, want := fmt.Sprint(cg.ActiveKeys()), "[tenant-a tenant-b]"; got != want {
+		t.Errorf("got active keys %s, want %s", got, want)
+	}
+	if got := cg.PendingCount("tenant-a"); got != 3 {
+		t.Errorf("got %d pending functions with tenant-a, want 3", got)
+	}
+	if got := cg.PendingCount("tenant-b"); got != 0 {
+		t.Errorf("got %d pending functions with tenant-b, want 0", got)
+	}
+	if got := cg.Running(); got != 2 {
+		t.Errorf("got %d running functions, want 2", got)
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cg.ActiveKeys(); len(got) != 0 {
+		t.Errorf("got active keys %v, want none", got)
+	}
+	if got := cg.PendingCount("tenant-a"); got != 0 {
+		t.Errorf("got %d pending functions with tenant-a, want 0", got)
+	}
+	if got := cg.Running(); got != 0 {
+		t.Errorf("got %d running functions, want 0", got)
+	}
+}
+
+func TestKeyBusyAndIdleKeys(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	for _, key := range []string{"tenant-c", "tenant-a"} {
+		cg.Go(key, func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("tenant-a", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	if !cg.KeyBusy("tenant-a") {
+		t.Error("tenant-a is not busy")
+	}
+	if cg.KeyBusy("tenant-c") {
+		t.Error("tenant-c is busy")
+	}
+	if got, want := fmt.Sprint(cg.IdleKeys()), "[tenant-c]"; got != want {
+		t.Errorf("got idle keys %s, want %s", got, want)
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(cg.IdleKeys()), "[tenant-a tenant-c]"; got != want {
+		t.Errorf("got idle keys %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,257 @@
+package concgroup
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrEmptyKey is the error of a function with the empty key "" rejected by EmptyKeyRejected.
+var ErrEmptyKey = errors.New("concgroup: empty key")
+
+// EmptyKeyPolicy is the policy for the empty key "".
+type EmptyKeyPolicy int
+
+const (
+	// EmptyKeyShared makes the empty key an anonymous lane like any other key:
+	// functions with the empty key run one at a time. It is the default.
+	EmptyKeyShared EmptyKeyPolicy = iota
+	// EmptyKeyUnlocked makes the empty key lock nothing:
+	// functions with the empty key run without key serialization like errgroup.Group.
+	EmptyKeyUnlocked
+	// EmptyKeyRejected rejects functions with the empty key:
+	// they are not called, and fail with ErrEmptyKey.
+	EmptyKeyRejected
+)
+
+// SetEmptyKeyPolicy sets the policy for the empty key "". The default is EmptyKeyShared.
+func (g *Group) SetEmptyKeyPolicy(p EmptyKeyPolicy) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.emptyKey = p
+}
+
+// SetKeyValidator sets validate to check every key at submission.
+// A function with a key for which validate returns an error is not called, and fails with the error.
+func (g *Group) SetKeyValidator(validate func(key string) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.validateKey = validate
+}
+
+// check checks the keys of s, removing the empty key if it locks nothing, and rejects keys whose circuit breakers are open.
+// g.mu must be held.
+func (g *Group) check(s *spec) error {
+	if g.validateKey != nil {
+		for _, key := range s.keys {
+			if err := g.validateKey(key); err != nil {
+				return err
+			}
+		}
+	}
+	for i, key := range s.keys {
+		if key != "" {
+			continue
+		}
+		switch g.emptyKey {
+		case EmptyKeyUnlocked:
+			keys := make([]string, 0, len(s.keys)-1)
+			keys = append(keys, s.keys[:i]...)
+			for _, k := range s.keys[i+1:] {
+				if k != "" {
+					keys = append(keys, k)
+				}
+			}
+			s.keys = keys
+			return g.breaker.check(s.keys)
+		case EmptyKeyRejected:
+			return ErrEmptyKey
+		}
+	}
+	return g.breaker.check(s.keys)
+}
+
+// ErrTooManyKeys is the error of a function rejected by KeyOverflowReject.
+var ErrTooManyKeys = errors.New("concgroup: too many keys")
+
+// OverflowKey is the key of the shared lane used by KeyOverflowShared instead of the keys beyond the limit.
+const OverflowKey = "concgroup:overflow"
+
+// KeyOverflowPolicy is the policy for a function with keys beyond the limit set by SetMaxKeys.
+type KeyOverflowPolicy int
+
+const (
+	// KeyOverflowBlock blocks the submission until the keys are available within the limit.
+	// TryGo and TryGoMulti return false instead.
+	KeyOverflowBlock KeyOverflowPolicy = iota
+	// KeyOverflowReject rejects the function: it is not called, and fails with ErrTooManyKeys.
+	KeyOverflowReject
+	// KeyOverflowShared locks OverflowKey instead of the keys beyond the limit,
+	// so that all such functions run one at a time in a shared lane. OverflowKey is not counted in the limit.
+	KeyOverflowShared
+)
+
+// SetMaxKeys limits the number of distinct keys of pending and running functions in this group to at most n,
+// to protect against unbounded key cardinality such as of keys derived from untrusted input like URLs or user IDs.
+// A function with keys beyond the limit is handled by p. The limit is shared with the subgroups of the group,
+// and the keys held by LockKey count as well. A negative value indicates no limit.
+func (g *Group) SetMaxKeys(n int, p KeyOverflowPolicy) {
+	g.init()
+	g.locks.mu.Lock()
+	defer g.locks.mu.Unlock()
+	g.locks.maxKeys = n
+	g.locks.overflow = p
+	g.locks.keyFreed.Broadcast()
+}
+
+// errKeysBusy is the error of admit that would block.
+var errKeysBusy = errors.New("concgroup: keys busy")
+
+// admit counts references to keys of a new task within the limit of distinct keys and the limit of pending functions,
+// and returns the keys to lock.
+// If block is false, it returns errKeysBusy instead of blocking.
+func (lt *lockTable) admit(keys []string, block bool) ([]string, error) {
+	unlock := lt.lockKeys(keys, false)
+	defer unlock()
+	if lt.striped() {
+		// There are no limits to check.
+		lt.ref(keys)
+		return keys, nil
+	}
+	requested := keys
+	for {
+		keys = requested
+		if lt.maxKeys >= 0 && lt.distinctKeys()+lt.newKeys(keys) > lt.maxKeys {
+			switch {
+			case lt.overflow == KeyOverflowReject:
+				return keys, ErrTooManyKeys
+			case lt.overflow == KeyOverflowShared:
+				keys = lt.overflowKeys(keys)
+			case !block:
+				return keys, errKeysBusy
+			default:
+				lt.keyFreed.Wait()
+				continue
+			}
+		}
+		if lt.full(keys) {
+			switch {
+			case lt.queueFull == QueueFullReject:
+				return keys, ErrQueueFull
+			case !block:
+				return keys, errKeysBusy
+			}
+			lt.keyFreed.Wait()
+			continue
+		}
+		lt.ref(keys)
+		return keys, nil
+	}
+}
+
+// distinctKeys returns the number of distinct keys counted in the limit.
+func (lt *lockTable) distinctKeys() int {
+	n := 0
+	for i := range lt.stripes {
+		n += len(lt.stripes[i].refs)
+	}
+	if lt.refs(OverflowKey) > 0 {
+		n--
+	}
+	return n
+}
+
+// refs returns the number of tasks referring to key.
+func (lt *lockTable) refs(key string) int {
+	return lt.stripe(key).refs[key]
+}
+
+// newKeys returns the number of distinct keys in keys that are not referenced yet.
+func (lt *lockTable) newKeys(keys []string) int {
+	n := 0
+	for i, key := range keys {
+		if lt.refs(key) > 0 || key == OverflowKey || contains(keys[:i], key) {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// overflowKeys returns keys with the keys beyond the limit replaced by OverflowKey.
+func (lt *lockTable) overflowKeys(keys []string) []string {
+	admitted := make([]string, 0, len(keys))
+	n := lt.distinctKeys()
+	overflowed := false
+	for _, key := range keys {
+		if lt.refs(key) > 0 || contains(admitted, key) {
+			admitted = append(admitted, key)
+			continue
+		}
+		if n < lt.maxKeys {
+			n++
+			admitted = append(admitted, key)
+			continue
+		}
+		overflowed = true
+	}
+	if overflowed && !contains(admitted, OverflowKey) {
+		admitted = append(admitted, OverflowKey)
+	}
+	sort.Strings(admitted)
+	return admitted
+}
+
+// ref counts references to keys.
+func (lt *lockTable) ref(keys []string) {
+	for _, key := range keys {
+		lt.stripe(key).refs[key]++
+	}
+}
+
+// unref removes references to keys counted by admit.
+func (lt *lockTable) unref(keys []string) {
+	defer lt.lockKeys(keys, false)()
+	lt.deref(keys)
+}
+
+// deref removes references to keys, deleting the lock of a key no task refers to.
+func (lt *lockTable) deref(keys []string) {
+	for _, key := range keys {
+		s := lt.stripe(key)
+		s.refs[key]--
+		if s.refs[key] > 0 {
+			continue
+		}
+		delete(s.refs, key)
+		lt.keyFreed.Broadcast()
+		if k, ok := s.locks[key]; ok && lt.pool == nil && len(k.holders) == 0 && len(k.waiters) == 0 {
+			delete(s.locks, key)
+		}
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SetKeyLimit lets up to n functions with key run at the same time, while the other keys stay serialized.
+// With SetKeyHashing, the limit applies to the lock shared by the keys of the same hash.
+// A value less than 1 restores the default of 1.
+func (g *Group) SetKeyLimit(key string, n int) {
+	g.init()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	if lt.keyLimits == nil {
+		lt.keyLimits = map[string]int{}
+	}
+	lt.keyLimits[key] = n
+	k := lt.lock(key)
+	k.limit = n
+	lt.notify(k)
+}
@@ -0,0 +1,244 @@
+package concgroup_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestEmptyKeyPolicy(t *testing.T) {
+	tests := []struct {
+		policy         concgroup.EmptyKeyPolicy
+		wantConcurrent bool
+		wantErr        error
+	}{
+		{concgroup.EmptyKeyShared, false, nil},
+		{concgroup.EmptyKeyUnlocked, true, nil},
+		{concgroup.EmptyKeyRejected, false, concgroup.ErrEmptyKey},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+			cg := new(concgroup.Group)
+			cg.SetEmptyKeyPolicy(tt.policy)
+			var running, maxRunning int64
+			for i := 0; i < 5; i++ {
+				cg.Go("", func() error {
+					n := atomic.AddInt64(&running, 1)
+					defer atomic.AddInt64(&running, -1)
+					for {
+						m := atomic.LoadInt64(&maxRunning)
+						if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+					return nil
+				})
+			}
+			err := cg.Wait()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			if got := maxRunning > 1; got != tt.wantConcurrent {
+				t.Errorf("got max %d running functions", maxRunning)
+			}
+			if tt.wantErr != nil && maxRunning != 0 {
+				t.Error("rejected function is called")
+			}
+		})
+	}
+}
+
+func TestEmptyKeyUnlockedMulti(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetEmptyKeyPolicy(concgroup.EmptyKeyUnlocked)
+	mu := atomic.Bool{}
+	for i := 0; i < 5; i++ {
+		cg.GoMulti([]string{"", "samegroup"}, func() error {
+			if !mu.CompareAndSwap(false, true) {
+				return errors.New("violate group concurrency")
+			}
+			defer mu.Store(false)
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyValidator(t *testing.T) {
+	t.Parallel()
+	errBadKey := errors.New("bad key")
+	cg := new(concgroup.Group)
+	cg.SetKeyValidator(func(key string) error {
+		if !strings.HasPrefix(key, "tenant-") {
+			return fmt.Errorf("%w: %q", errBadKey, key)
+		}
+		return nil
+	})
+	var called atomic.Int64
+	cg.Go("tenant-a", func() error {
+		called.Add(1)
+		return nil
+	})
+	cg.GoMulti([]string{"tenant-a", "junk"}, func() error {
+		called.Add(1)
+		return nil
+	})
+	if cg.TryGo("junk", func() error {
+		called.Add(1)
+		return nil
+	}) {
+		t.Error("TryGo with an invalid key succeeded")
+	}
+	if err := cg.Wait(); !errors.Is(err, errBadKey) {
+		t.Errorf("got %v, want %v", err, errBadKey)
+	}
+	if got := called.Load(); got != 1 {
+		t.Errorf("got %d calls, want 1", got)
+	}
+}
+
+func TestMaxKeys(t *testing.T) {
+	const maxKeys = 3
+	tests := []struct {
+		policy  concgroup.KeyOverflowPolicy
+		wantErr error
+	}{
+		{concgroup.KeyOverflowBlock, nil},
+		{concgroup.KeyOverflowReject, concgroup.ErrTooManyKeys},
+		{concgroup.KeyOverflowShared, nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+			cg := new(concgroup.Group)
+			cg.SetMaxKeys(maxKeys, tt.policy)
+			var running, maxRunning, called int64
+			for i := 0; i < 10; i++ {
+				cg.Go(fmt.Sprintf("key-%d", i), func() error {
+					atomic.AddInt64(&called, 1)
+					n := atomic.AddInt64(&running, 1)
+					defer atomic.AddInt64(&running, -1)
+					for {
+						m := atomic.LoadInt64(&maxRunning)
+						if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+					return nil
+				})
+			}
+			err := cg.Wait()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			wantMaxRunning := int64(maxKeys)
+			if tt.policy == concgroup.KeyOverflowShared {
+				// The overflow lane is not counted in the limit.
+				wantMaxRunning++
+			}
+			if maxRunning > wantMaxRunning {
+				t.Errorf("got max %d running functions, want at most %d", maxRunning, wantMaxRunning)
+			}
+			wantCalled := int64(10)
+			if tt.wantErr != nil {
+				wantCalled = maxKeys
+			}
+			if called != wantCalled {
+				t.Errorf("got %d calls, want %d", called, wantCalled)
+			}
+		})
+	}
+}
+
+func TestMaxKeysTryGo(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetMaxKeys(1, concgroup.KeyOverflowBlock)
+	release := make(chan struct{})
+	cg.Go("a", func() error {
+		<-release
+		return nil
+	})
+	if !cg.TryGo("a", func() error { return nil }) {
+		t.Error("TryGo with a referenced key failed")
+	}
+	if cg.TryGo("b", func() error { return nil }) {
+		t.Error("TryGo beyond the limit succeeded")
+	}
+	close(release)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if !cg.TryGo("b", func() error { return nil }) {
+		t.Error("TryGo after the keys are freed failed")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyLimit(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetKeyLimit("tenant-a", 3)
+	var running, maxRunning [2]int64
+	for i := 0; i < 20; i++ {
+		j := i % 2
+		key := []string{"tenant-a", "tenant-b"}[j]
+		cg.Go(key, func() error {
+			n := atomic.AddInt64(&running[j], 1)
+			defer atomic.AddInt64(&running[j], -1)
+			for {
+				m := atomic.LoadInt64(&maxRunning[j])
+				if n <= m || atomic.CompareAndSwapInt64(&maxRunning[j], m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning[0] != 3 {
+		t.Errorf("got max %d running functions of tenant-a, want 3", maxRunning[0])
+	}
+	if maxRunning[1] != 1 {
+		t.Errorf("got max %d running functions of tenant-b, want 1", maxRunning[1])
+	}
+}
+
+func TestMaxKeysSubgroup(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetMaxKeys(1, concgroup.KeyOverflowReject)
+	release := make(chan struct{})
+	cg.Go("a", func() error {
+		<-release
+		return nil
+	})
+	sub := cg.Subgroup()
+	sub.Go("b", func() error { return nil })
+	if err := sub.Wait(); !errors.Is(err, concgroup.ErrTooManyKeys) {
+		t.Errorf("got %v, want %v", err, concgroup.ErrTooManyKeys)
+	}
+	close(release)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package keyfuncs provides common functions deriving keys of concgroup.Group.
+//
+//	cg.Go(keyfuncs.Host(url), func() error {
+//		// Fetch URL sequentially by host
+//	})
+//
+// The functions return the empty key "" for input they cannot derive a key from; see concgroup.EmptyKeyPolicy.
+package keyfuncs
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultPorts is the default port per URL scheme.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// Host returns the lowercased host name of rawURL without the port.
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// HostPort returns the lowercased host name and the port of rawURL, filling in the default port of the scheme.
+func HostPort(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPorts[strings.ToLower(u.Scheme)]
+	}
+	if port == "" {
+		return strings.ToLower(u.Hostname())
+	}
+	return net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+// PathPrefix returns the host and the first depth segments of the path of rawURL, such as "example.com/api/v1" for depth 2.
+func PathPrefix(rawURL string, depth int) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if len(segments) > depth {
+		segments = segments[:depth]
+	}
+	return strings.Join(append([]string{strings.ToLower(u.Hostname())}, segments...), "/")
+}
+
+// Shard returns one of n keys ("shard-0" to "shard-<n-1>") by the FNV-1a hash of key,
+// to run functions with at most n different keys at the same time.
+func Shard(key string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime32
+	}
+	return "shard-" + strconv.FormatUint(uint64(h%uint32(n)), 10)
+}
+
+// Tenant returns the tenant in the header name of h, such as "X-Tenant-ID".
+func Tenant(h http.Header, name string) string {
+	return strings.TrimSpace(h.Get(name))
+}
@@ -0,0 +1,94 @@
+package keyfuncs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://Go.dev/dl/", "go.dev"},
+		{"http://www.google.com:8080/", "www.google.com"},
+		{"http://[::1]:8080/", "::1"},
+		{"::", ""},
+	}
+	for _, tt := range tests {
+		if got := Host(tt.in); got != tt.want {
+			t.Errorf("Host(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHostPort(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://go.dev/dl/", "go.dev:443"},
+		{"http://go.dev/", "go.dev:80"},
+		{"http://go.dev:8080/", "go.dev:8080"},
+		{"unknown://go.dev/", "go.dev"},
+		{"http://[::1]/", "[::1]:80"},
+		{"/relative", ""},
+	}
+	for _, tt := range tests {
+		if got := HostPort(tt.in); got != tt.want {
+			t.Errorf("HostPort(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	tests := []struct {
+		in    string
+		depth int
+		want  string
+	}{
+		{"https://example.com/api/v1/users/1", 2, "example.com/api/v1"},
+		{"https://example.com/api", 2, "example.com/api"},
+		{"https://example.com//api//v1/", 1, "example.com/api"},
+		{"https://example.com/api/v1", 0, "example.com"},
+		{"https://Example.com:8443/api", 1, "example.com/api"},
+		{"/api/v1", 1, ""},
+	}
+	for _, tt := range tests {
+		if got := PathPrefix(tt.in, tt.depth); got != tt.want {
+			t.Errorf("PathPrefix(%q, %d) = %q, want %q", tt.in, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestShard(t *testing.T) {
+	seen := map[string]struct{}{}
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		got := Shard(key, 4)
+		if !strings.HasPrefix(got, "shard-") {
+			t.Errorf("Shard(%q, 4) = %q", key, got)
+		}
+		if Shard(key, 4) != got {
+			t.Errorf("Shard(%q, 4) is not stable", key)
+		}
+		seen[got] = struct{}{}
+	}
+	if len(seen) > 4 {
+		t.Errorf("got %d shards, want at most 4", len(seen))
+	}
+	if got := Shard("a", 0); got != "" {
+		t.Errorf("Shard(a, 0) = %q, want empty", got)
+	}
+}
+
+func TestTenant(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Tenant-ID", " acme ")
+	if got := Tenant(h, "x-tenant-id"); got != "acme" {
+		t.Errorf("got %q, want acme", got)
+	}
+	if got := Tenant(h, "X-Other"); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+}
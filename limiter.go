@@ -0,0 +1,134 @@
+package concgroup
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter limits the number of active goroutines in a Group.
+type limiter struct {
+	mu     sync.Mutex
+	limit  int
+	active int
+	// changed is closed and replaced when a goroutine releases or the limit is changed.
+	changed   chan struct{}
+	rampFrom  int
+	rampOver  time.Duration
+	rampStart time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		limit:   -1,
+		changed: make(chan struct{}),
+	}
+}
+
+// setLimit sets the limit. A negative value indicates no limit.
+func (l *limiter) setLimit(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = n
+	l.rampOver = 0
+	l.broadcast()
+}
+
+// setRampUp sets the limit to raise from `from` to `to` over the duration from the start of a run.
+func (l *limiter) setRampUp(from, to int, over time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = to
+	l.rampFrom = from
+	l.rampOver = over
+	l.rampStart = time.Time{}
+	l.broadcast()
+}
+
+// acquire blocks until w more active goroutines are within the limit, and counts them.
+// A weight larger than the limit is admitted alone.
+func (l *limiter) acquire(w int) {
+	l.mu.Lock()
+	for {
+		n, next := l.current(time.Now())
+		if l.fits(n, w) {
+			break
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		if next.IsZero() {
+			<-changed
+		} else {
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-changed:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+		l.mu.Lock()
+	}
+	l.active += w
+	l.mu.Unlock()
+}
+
+// tryAcquire counts w more active goroutines only if they are within the limit.
+func (l *limiter) tryAcquire(w int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n, _ := l.current(time.Now()); !l.fits(n, w) {
+		return false
+	}
+	l.active += w
+	return true
+}
+
+// available reports whether w more active goroutines are within the limit now, without counting them.
+func (l *limiter) available(w int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, _ := l.current(time.Now())
+	return l.fits(n, w)
+}
+
+// fits reports whether w more active goroutines are within the limit n.
+func (l *limiter) fits(n, w int) bool {
+	return n < 0 || l.active+w <= n || (l.active == 0 && n > 0)
+}
+
+// release counts w less active goroutines.
+func (l *limiter) release(w int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active -= w
+	l.broadcast()
+}
+
+// reset restarts the ramp up for the next run.
+func (l *limiter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rampStart = time.Time{}
+}
+
+// current returns the effective limit at now, and the time it is raised next (zero if never).
+func (l *limiter) current(now time.Time) (int, time.Time) {
+	if l.rampOver <= 0 || l.limit < 0 || l.rampFrom >= l.limit {
+		return l.limit, time.Time{}
+	}
+	if l.rampStart.IsZero() {
+		l.rampStart = now
+	}
+	elapsed := now.Sub(l.rampStart)
+	if elapsed >= l.rampOver {
+		return l.limit, time.Time{}
+	}
+	steps := int64(l.limit - l.rampFrom)
+	n := l.rampFrom + int(int64(elapsed)*steps/int64(l.rampOver))
+	next := l.rampStart.Add(time.Duration((int64(n-l.rampFrom+1)*int64(l.rampOver) + steps - 1) / steps))
+	return n, next
+}
+
+func (l *limiter) broadcast() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
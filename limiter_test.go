@@ -0,0 +1,97 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetRampUp(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetRampUp(1, 4, 300*time.Millisecond)
+	var started int64
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for i := 0; i < 8; i++ {
+			cg.Go(fmt.Sprintf("group-%d", i), func() error {
+				atomic.AddInt64(&started, 1)
+				time.Sleep(800 * time.Millisecond)
+				return nil
+			})
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt64(&started); got != 1 {
+		t.Errorf("got %d started, want 1", got)
+	}
+	time.Sleep(400 * time.Millisecond)
+	if got := atomic.LoadInt64(&started); got != 4 {
+		t.Errorf("got %d started, want 4", got)
+	}
+	<-submitted
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if got := cg.Stats().MaxConcurrency; got != 4 {
+		t.Errorf("got %d, want 4", got)
+	}
+}
+
+func TestGoWeighted(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetLimit(4)
+	var used int64
+	for i := 0; i < 30; i++ {
+		w := []int{1, 3, 10}[i%3]
+		cg.GoWeighted(fmt.Sprintf("key-%d", i), w, func() error {
+			n := atomic.AddInt64(&used, int64(w))
+			defer atomic.AddInt64(&used, -int64(w))
+			if n > 4 && n != int64(w) {
+				return fmt.Errorf("got %d slots used, want at most 4 or a heavier function alone", n)
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTryGoWeighted(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetLimit(4)
+	release := make(chan struct{})
+	if !cg.TryGoWeighted("a", 3, func() error {
+		<-release
+		return nil
+	}) {
+		t.Error("TryGoWeighted within the limit failed")
+	}
+	if cg.TryGoMultiWeighted([]string{"b", "c"}, 2, func() error { return nil }) {
+		t.Error("TryGoMultiWeighted beyond the limit succeeded")
+	}
+	if !cg.TryGo("d", func() error {
+		<-release
+		return nil
+	}) {
+		t.Error("TryGo within the rest of the limit failed")
+	}
+	close(release)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if !cg.TryGoWeighted("e", 10, func() error { return nil }) {
+		t.Error("TryGoWeighted heavier than the limit failed with no active goroutine")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
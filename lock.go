@@ -0,0 +1,778 @@
+package concgroup
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrCanceled is the error of a function canceled before it is called.
+var ErrCanceled = errors.New("concgroup: canceled")
+
+// spec is the specification of a function submitted to the Group.
+type spec struct {
+	keys      []string
+	priority  int
+	tags      []string
+	resources map[string]int
+	// patterns is the units of the limiters of SetLimitPattern among resources, defined while they are used.
+	patterns map[string]int
+	timeout  time.Duration
+	// slots is the number of slots of the limit of the group the function consumes. Zero is treated as 1.
+	slots int
+	// roundRobin reports whether the slots are taken once the function holds its key locks by LimitRoundRobin.
+	roundRobin bool
+	// atTurn reports whether the slots are taken at the turn of the function while replaying a schedule.
+	atTurn bool
+	// sem is the external semaphore and its weight to acquire.
+	sem    *semaphore.Weighted
+	weight int64
+	// locker is the backend of the key locks to acquire in addition to the ones of the table.
+	locker KeyLocker
+	// desc is the descriptor of a function submitted by GoTask.
+	desc *TaskDescriptor
+	// modes is the modes of the keys not locked exclusively.
+	modes map[string]KeyMode
+	// deferred reports whether the function is submitted on behalf of an earlier call such as GoAfter,
+	// so that it is not rejected by closing the group in between.
+	deferred bool
+	// done is called with the result of the function, whether it is called or not.
+	done func(r TaskResult)
+	// owner is the group the function is submitted to.
+	owner *Group
+	// handle is the handle of the function returned by GoHandle.
+	handle *Task
+	// caller is the task whose function calls LockKey with its context.
+	caller *task
+	// site is the call site of the submission recorded by SetLockOrderAuditor.
+	site string
+}
+
+// slotsOf returns the number of slots of the limit of the group the function consumes.
+func (s *spec) slotsOf() int {
+	if s.slots < 1 {
+		return 1
+	}
+	return s.slots
+}
+
+// mode returns the mode t locks key in.
+func (s *spec) mode(key string) KeyMode {
+	if m, ok := s.modes[key]; ok {
+		return m
+	}
+	return Exclusive
+}
+
+// task is a function submitted to the Group together with the keys it locks.
+type task struct {
+	spec
+	seq uint64
+	// order is the keys of the locks to take in order, set by add.
+	order []string
+	// submitted is the time of the submission.
+	submitted time.Time
+	ctx       context.Context
+	cancel    context.CancelFunc
+	held      []*keyLock
+	waiting   *keyLock
+	// waitMode is the mode t waits for waiting in.
+	waitMode KeyMode
+	wake     chan struct{}
+	wounded  bool
+	running  bool
+	canceled bool
+	cause    error
+	// deadlocked and overdue report whether t has been detected in a cycle or waiting too long by SetDeadlockDetector.
+	deadlocked bool
+	overdue    bool
+}
+
+// keyLock is the lock of a key.
+type keyLock struct {
+	key string
+	// holders is the tasks holding the lock, and the mode each of them holds it in.
+	holders map[*task]KeyMode
+	waiters []*task
+	// limit is the number of functions holding the lock exclusively at the same time set by SetKeyLimit. Zero is treated as 1.
+	limit int
+}
+
+// stripeCount is the number of stripes of a lockTable.
+const stripeCount = 64
+
+// lockTable manages the key locks of a Group.
+//
+// The state of keys is striped by the hash of the key, so that the tasks with keys of different stripes lock, wait and
+// unlock without serializing each other: the operations of a task read-lock mu and lock the stripes of its keys in
+// index order, which keeps multi-key tasks from deadlocking. The operations across keys, such as canceling tasks,
+// and the features needing a consistent view across keys, such as priority inheritance, the limits of the keys and
+// the pending functions, WaitDie and WoundWait, and the deadlock detector, write-lock mu instead (see striped).
+type lockTable struct {
+	mu      sync.RWMutex
+	stripes [stripeCount]stripe
+	// scopes is the state of the groups sharing the table by Subgroup.
+	scopes map[*Group]*scope
+	// paused is the set of keys paused by PauseKey.
+	paused   map[string]struct{}
+	seq      atomic.Uint64
+	strategy Strategy
+	// prioritized reports whether a task with a priority has been added, so that priority inheritance applies.
+	prioritized bool
+	// keyLimits is the limits set by SetKeyLimit.
+	keyLimits map[string]int
+	// pool is the fixed locks shared by keys with the same hash. It is nil unless SetKeyHashing is set.
+	pool         []*keyLock
+	maxKeys      int
+	overflow     KeyOverflowPolicy
+	runningTasks atomic.Int64
+	maxPending   int
+	queueFull    QueueFullPolicy
+	// aging is the period set by SetPriorityAging.
+	aging time.Duration
+	// detector is the deadlock detector set by SetDeadlockDetector. It is nil unless set.
+	detector *detector
+	// auditor is the lock order auditor set by SetLockOrderAuditor. It is nil unless set.
+	auditor atomic.Pointer[auditor]
+	// keyFreed is signaled when a key is freed or a queue has room.
+	keyFreed *sync.Cond
+}
+
+// stripe is the state of the keys with the same hash in a lockTable.
+type stripe struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+	// tasks is the tasks whose first key is of the stripe.
+	tasks map[*task]struct{}
+	// refs is the number of tasks referring to each key.
+	refs map[string]int
+	// running is the number of running tasks with each key.
+	running map[string]int
+}
+
+func newLockTable() *lockTable {
+	lt := &lockTable{
+		strategy:   SortedOrder,
+		maxKeys:    -1,
+		maxPending: -1,
+	}
+	for i := range lt.stripes {
+		s := &lt.stripes[i]
+		s.locks = map[string]*keyLock{}
+		s.tasks = map[*task]struct{}{}
+		s.refs = map[string]int{}
+		s.running = map[string]int{}
+	}
+	lt.keyFreed = sync.NewCond(&lt.mu)
+	return lt
+}
+
+// stripe returns the stripe of key.
+func (lt *lockTable) stripe(key string) *stripe {
+	return &lt.stripes[lt.stripeIndex(key)]
+}
+
+// stripeIndex returns the index of the stripe of key. The keys sharing a lock by SetKeyHashing are of the same stripe.
+func (lt *lockTable) stripeIndex(key string) int {
+	h := hashKey(key)
+	if lt.pool != nil {
+		h %= uint64(len(lt.pool))
+	}
+	return int(h % stripeCount)
+}
+
+// striped reports whether the operations of tasks lock only the stripes of their keys. lt.mu must be held.
+func (lt *lockTable) striped() bool {
+	return !lt.prioritized && lt.aging == 0 && lt.maxKeys < 0 && lt.maxPending < 0 && lt.detector == nil &&
+		(lt.strategy == SortedOrder || lt.strategy == AllOrNothing)
+}
+
+// lockKeys locks the table for an operation of a task with keys, and returns the function to unlock it.
+// It read-locks the table and locks the stripes of keys in index order while the table is striped and exclusive is
+// false, otherwise it write-locks the table.
+func (lt *lockTable) lockKeys(keys []string, exclusive bool) (unlock func()) {
+	if !exclusive {
+		lt.mu.RLock()
+		if lt.striped() {
+			ss := lt.stripesOf(keys)
+			for _, s := range ss {
+				s.mu.Lock()
+			}
+			return func() {
+				for i := len(ss) - 1; i >= 0; i-- {
+					ss[i].mu.Unlock()
+				}
+				lt.mu.RUnlock()
+			}
+		}
+		lt.mu.RUnlock()
+	}
+	lt.mu.Lock()
+	return lt.mu.Unlock
+}
+
+// lockTask locks the table for an operation of t like lockKeys.
+func (lt *lockTable) lockTask(t *task) (unlock func()) {
+	return lt.lockKeys(t.keys, t.priority != 0)
+}
+
+// stripesOf returns the stripes of keys in index order without duplicates. It is the first stripe for no keys.
+func (lt *lockTable) stripesOf(keys []string) []*stripe {
+	if len(keys) == 0 {
+		return []*stripe{&lt.stripes[0]}
+	}
+	if len(keys) == 1 {
+		return []*stripe{lt.stripe(keys[0])}
+	}
+	idx := make([]int, 0, len(keys))
+	for _, key := range keys {
+		idx = append(idx, lt.stripeIndex(key))
+	}
+	sort.Ints(idx)
+	ss := make([]*stripe, 0, len(idx))
+	for i, j := range idx {
+		if i > 0 && j == idx[i-1] {
+			continue
+		}
+		ss = append(ss, &lt.stripes[j])
+	}
+	return ss
+}
+
+// tasksStripe returns the stripe holding t in its tasks.
+func (lt *lockTable) tasksStripe(t *task) *stripe {
+	if len(t.keys) == 0 {
+		return &lt.stripes[0]
+	}
+	return lt.stripe(t.keys[0])
+}
+
+// eachTask calls f with every task in the table. lt.mu must be write-locked.
+func (lt *lockTable) eachTask(f func(t *task)) {
+	for i := range lt.stripes {
+		for t := range lt.stripes[i].tasks {
+			f(t)
+		}
+	}
+}
+
+// newTask returns a new task of s with a context derived from ctx.
+// The keys of s must be admitted by admit.
+// If queue is true, the task is queued as a waiter of its first key right away,
+// so that functions with the same key and priority take the lock in the order of submission.
+func (lt *lockTable) newTask(ctx context.Context, s spec, queue bool) *task {
+	t := newTask(ctx, s)
+	defer lt.lockTask(t)()
+	lt.add(t)
+	if queue && !t.canceled && len(t.order) > 0 {
+		lt.wait(t.order[0], t)
+	}
+	return t
+}
+
+// tryNewTask returns a new task holding the locks of its keys, or nil if any of them is held or waited for now.
+// The references to the keys are removed if it returns nil.
+func (lt *lockTable) tryNewTask(ctx context.Context, s spec) *task {
+	t := newTask(ctx, s)
+	defer lt.lockTask(t)()
+	t.seq = lt.seq.Load() + 1
+	t.order = lt.lockOrder(t)
+	for _, key := range t.order {
+		if !lt.available(key, t) || len(lt.lock(key).waiters) > 0 {
+			t.cancel()
+			lt.deref(t.keys)
+			return nil
+		}
+	}
+	for _, key := range t.order {
+		lt.take(key, t)
+	}
+	lt.add(t)
+	return t
+}
+
+// newTask returns a new task of s with a context derived from ctx.
+// The context is derived before locking the table as deriving it locks the parent.
+func newTask(ctx context.Context, s spec) *task {
+	t := &task{
+		spec:      s,
+		submitted: time.Now(),
+		wake:      make(chan struct{}, 1),
+	}
+	if s.timeout > 0 {
+		t.ctx, t.cancel = context.WithTimeout(ctx, s.timeout)
+	} else {
+		t.ctx, t.cancel = context.WithCancel(ctx)
+	}
+	// Let LockKey called with the context of the function know the caller for SetDeadlockDetector.
+	t.ctx = context.WithValue(t.ctx, taskKey{}, t)
+	return t
+}
+
+// add numbers t and registers it, canceling it if its group is aborted or its keys are quarantined.
+// lt.mu must be write-locked for t with a priority.
+func (lt *lockTable) add(t *task) {
+	t.seq = lt.seq.Add(1)
+	t.order = lt.lockOrder(t)
+	lt.tasksStripe(t).tasks[t] = struct{}{}
+	if t.priority != 0 {
+		lt.prioritized = true
+	}
+	if sc := lt.scopes[t.owner]; sc != nil {
+		if sc.aborted {
+			lt.cancelTask(t, ErrCanceled)
+		}
+		if sc.quarantined(t.keys) {
+			lt.cancelTask(t, ErrQuarantined)
+		}
+	}
+}
+
+// acquire blocks until t holds the locks of all its keys.
+// It returns the cause (such as ErrCanceled) if t is canceled before that.
+func (lt *lockTable) acquire(t *task) error {
+	unlock := lt.lockTask(t)
+	defer func() { unlock() }()
+	for {
+		if t.canceled {
+			lt.unwait(t)
+			lt.releaseAll(t)
+			lt.done(t)
+			return t.cause
+		}
+		if t.wounded {
+			t.wounded = false
+			lt.releaseAll(t)
+		}
+		if lt.strategy.acquire(lt, t) {
+			break
+		}
+		deadlock := lt.detect(t)
+		if t.canceled {
+			continue
+		}
+		d := lt.detector
+		overdue, stop := lt.overdue(t)
+		unlock()
+		if deadlock != nil {
+			d.report(deadlock)
+		}
+		select {
+		case <-t.wake:
+		case <-t.ctx.Done():
+		case <-overdue:
+		}
+		stop()
+		unlock = lt.lockTask(t)
+		if !t.canceled && t.ctx.Err() != nil {
+			// The context of the group is canceled or the timeout of t has expired.
+			lt.cancelTask(t, context.Cause(t.ctx))
+		}
+	}
+	lt.started(t)
+	return nil
+}
+
+// release releases the locks of all keys held by t.
+// It reports whether t has been canceled while running.
+func (lt *lockTable) release(t *task) bool {
+	defer lt.lockTask(t)()
+	lt.stopped(t)
+	lt.releaseAll(t)
+	lt.done(t)
+	return t.canceled
+}
+
+// done removes finished t from the table.
+func (lt *lockTable) done(t *task) {
+	t.cancel()
+	delete(lt.tasksStripe(t).tasks, t)
+	lt.deref(t.keys)
+	if lt.maxPending >= 0 {
+		lt.keyFreed.Broadcast()
+	}
+}
+
+// scope is the state of a group in the table.
+type scope struct {
+	// aborted makes new tasks canceled.
+	aborted bool
+	// quarantine is the set of keys whose new tasks are canceled.
+	quarantine map[string]struct{}
+}
+
+// scope returns the state of the group g, creating it if necessary.
+func (lt *lockTable) scope(g *Group) *scope {
+	sc, ok := lt.scopes[g]
+	if !ok {
+		if lt.scopes == nil {
+			lt.scopes = map[*Group]*scope{}
+		}
+		sc = &scope{}
+		lt.scopes[g] = sc
+	}
+	return sc
+}
+
+// cancel cancels the tasks of the group g for which match returns true with cause.
+// Waiting tasks give up acquiring the locks, and the context of running tasks is canceled.
+func (lt *lockTable) cancel(g *Group, match func(t *task) bool, cause error) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.eachTask(func(t *task) {
+		if t.owner == g && !t.canceled && match(t) {
+			lt.cancelTask(t, cause)
+		}
+	})
+}
+
+// abort cancels all the tasks of the group g including the ones created later.
+func (lt *lockTable) abort(g *Group) {
+	lt.abandon(g)
+}
+
+// abandon cancels all the tasks of the group g like abort, and returns the sorted keys of the tasks.
+func (lt *lockTable) abandon(g *Group) []string {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.scope(g).aborted = true
+	var keys []string
+	lt.eachTask(func(t *task) {
+		if t.owner != g {
+			return
+		}
+		if !t.canceled {
+			lt.cancelTask(t, ErrCanceled)
+		}
+		keys = append(keys, t.keys...)
+	})
+	return sortedKeys(keys)
+}
+
+// canceledBy marks t whose context is done as canceled, and returns the cause of the cancellation.
+func (lt *lockTable) canceledBy(t *task) error {
+	defer lt.lockTask(t)()
+	if !t.canceled {
+		lt.cancelTask(t, context.Cause(t.ctx))
+	}
+	return t.cause
+}
+
+func (lt *lockTable) cancelTask(t *task, cause error) {
+	t.canceled = true
+	t.cause = cause
+	t.cancel()
+}
+
+// holds reports whether t holds the lock of key in the mode t needs.
+func (lt *lockTable) holds(t *task, key string) bool {
+	m, ok := lt.lock(key).holders[t]
+	return ok && join(m, t.mode(key)) == m
+}
+
+// lockOrder returns the keys of t in the order to take their locks.
+// With SetKeyHashing, the keys are sorted by the index of their locks in the pool, and each lock is taken once by
+// one of its keys in the mode covering all of them, so that tasks never take the same locks in opposite orders.
+// Otherwise it is the sorted keys of t.
+func (lt *lockTable) lockOrder(t *task) []string {
+	if lt.pool == nil {
+		return t.keys
+	}
+	m := uint64(len(lt.pool))
+	order := make([]string, len(t.keys))
+	copy(order, t.keys)
+	sort.SliceStable(order, func(i, j int) bool {
+		return hashKey(order[i])%m < hashKey(order[j])%m
+	})
+	n := 0
+	for i, key := range order {
+		if i > 0 && hashKey(key)%m == hashKey(order[n-1])%m {
+			if prev := order[n-1]; join(t.mode(prev), t.mode(key)) != t.mode(prev) {
+				// Lock the slot by prev in the mode covering both keys.
+				modes := map[string]KeyMode{}
+				for k, v := range t.modes {
+					modes[k] = v
+				}
+				modes[prev] = join(t.mode(prev), t.mode(key))
+				t.modes = modes
+			}
+			continue
+		}
+		order[n] = key
+		n++
+	}
+	return order[:n]
+}
+
+// lock returns the lock of key, creating it if necessary.
+func (lt *lockTable) lock(key string) *keyLock {
+	if lt.pool != nil {
+		return lt.pool[hashKey(key)%uint64(len(lt.pool))]
+	}
+	s := lt.stripe(key)
+	k, ok := s.locks[key]
+	if !ok {
+		k = &keyLock{key: key, limit: lt.keyLimits[key]}
+		s.locks[key] = k
+	}
+	return k
+}
+
+// before reports whether a takes precedence over b in the wait queue of a key.
+// A task with higher effective priority (aged by SetPriorityAging) comes first, then an older one.
+func (lt *lockTable) before(a, b *task) bool {
+	if !lt.prioritized && lt.aging == 0 {
+		// Every task has the priority 0, and the waiters of the locks held by a and b may be of other stripes.
+		return a.seq < b.seq
+	}
+	return precedes(a, b, lt.priority(a, nil), lt.priority(b, nil), lt.aging)
+}
+
+// priority returns the effective priority of t.
+// A task holding key locks inherits the highest effective priority of the tasks waiting for them,
+// so that a low priority task does not keep a high priority task waiting behind other tasks (priority inversion).
+func (lt *lockTable) priority(t *task, seen map[*task]struct{}) int {
+	p := t.priority
+	for _, k := range t.held {
+		for _, w := range k.waiters {
+			if len(w.held) == 0 {
+				if w.priority > p {
+					p = w.priority
+				}
+				continue
+			}
+			if seen == nil {
+				seen = map[*task]struct{}{t: {}}
+			}
+			if _, ok := seen[w]; ok {
+				continue
+			}
+			seen[w] = struct{}{}
+			if wp := lt.priority(w, seen); wp > p {
+				p = wp
+			}
+		}
+	}
+	return p
+}
+
+// next returns the waiter of k that takes precedence over all others.
+func (lt *lockTable) next(k *keyLock) *task {
+	var n *task
+	for _, w := range k.waiters {
+		if n == nil || lt.before(w, n) {
+			n = w
+		}
+	}
+	return n
+}
+
+// waiters returns the waiters of k in the order of precedence.
+func (lt *lockTable) waiters(k *keyLock) []*task {
+	ws := append([]*task(nil), k.waiters...)
+	sort.SliceStable(ws, func(i, j int) bool { return lt.before(ws[i], ws[j]) })
+	return ws
+}
+
+// fits reports whether t can take k in mode with the holders of k and the waiters ahead of t taking it first:
+// functions in compatible modes share k with each other, and up to the limit of k in exclusive mode share it.
+func (k *keyLock) fits(t *task, mode KeyMode, ahead []*task) bool {
+	nExclusive := 0
+	fit := func(o *task, m KeyMode) bool {
+		if o == t {
+			return true
+		}
+		if mode == Exclusive && m == Exclusive {
+			nExclusive++
+			return true
+		}
+		return compatible(mode, m)
+	}
+	for h, m := range k.holders {
+		if !fit(h, m) {
+			return false
+		}
+	}
+	for _, w := range ahead {
+		if !fit(w, w.waitMode) {
+			return false
+		}
+	}
+	return nExclusive < k.capacity()
+}
+
+// capacity returns the number of functions holding k exclusively at the same time.
+func (k *keyLock) capacity() int {
+	if k.limit < 1 {
+		return 1
+	}
+	return k.limit
+}
+
+// blockers returns the holders of k keeping t from taking k in the mode of key.
+func (lt *lockTable) blockers(key string, t *task) []*task {
+	k := lt.lock(key)
+	mode := t.mode(key)
+	if k.fits(t, mode, nil) {
+		return nil
+	}
+	var hs []*task
+	for h, m := range k.holders {
+		if h != t && !compatible(mode, m) {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
+
+// available reports whether t can take the lock of key in the mode of key now.
+// If the lock is free but waiters take precedence over t, they are woken up.
+func (lt *lockTable) available(key string, t *task) bool {
+	if _, ok := lt.paused[key]; ok {
+		return false
+	}
+	k := lt.lock(key)
+	mode := t.mode(key)
+	if !k.fits(t, mode, nil) {
+		return false
+	}
+	var ahead []*task
+	for _, w := range k.waiters {
+		if w != t && lt.before(w, t) {
+			ahead = append(ahead, w)
+		}
+	}
+	if !k.fits(t, mode, ahead) {
+		lt.notify(k)
+		return false
+	}
+	return true
+}
+
+// take makes t a holder of the lock of key in the mode of key.
+func (lt *lockTable) take(key string, t *task) {
+	k := lt.lock(key)
+	if k.holders == nil {
+		k.holders = map[*task]KeyMode{}
+	}
+	if m, ok := k.holders[t]; ok {
+		// Upgrade the lock of another key of the same hash to the mode covering both.
+		k.holders[t] = join(m, t.mode(key))
+	} else {
+		k.holders[t] = t.mode(key)
+		t.held = append(t.held, k)
+	}
+	if t.waiting == k {
+		lt.unwait(t)
+	}
+	// Waiters holding other keys may need to reconsider now that the holders have changed.
+	for _, w := range k.waiters {
+		if len(w.held) > 0 {
+			wake(w)
+		}
+	}
+}
+
+// tryTake makes t a holder of the lock of key if it is available.
+func (lt *lockTable) tryTake(key string, t *task) bool {
+	if !lt.available(key, t) {
+		return false
+	}
+	lt.take(key, t)
+	return true
+}
+
+// wait queues t as a waiter of the lock of key.
+func (lt *lockTable) wait(key string, t *task) {
+	k := lt.lock(key)
+	if t.waiting == k && t.waitMode == t.mode(key) {
+		return
+	}
+	lt.unwait(t)
+	t.waiting = k
+	t.waitMode = t.mode(key)
+	k.waiters = append(k.waiters, t)
+}
+
+// unwait removes t from the wait queue it is in.
+func (lt *lockTable) unwait(t *task) {
+	k := t.waiting
+	if k == nil {
+		return
+	}
+	t.waiting = nil
+	for i, w := range k.waiters {
+		if w == t {
+			k.waiters = append(k.waiters[:i], k.waiters[i+1:]...)
+			break
+		}
+	}
+	lt.notify(k)
+}
+
+// releaseAll releases the locks of all keys held by t.
+func (lt *lockTable) releaseAll(t *task) {
+	for _, k := range t.held {
+		delete(k.holders, t)
+		lt.notify(k)
+	}
+	t.held = nil
+}
+
+// notify wakes up the waiters of k that take precedence and can take k now together.
+func (lt *lockTable) notify(k *keyLock) {
+	n := lt.next(k)
+	if n == nil || !k.fits(n, n.waitMode, nil) {
+		return
+	}
+	wake(n)
+	if n.waitMode == Exclusive && k.capacity() == 1 {
+		return
+	}
+	ws := lt.waiters(k)
+	for i := 1; i < len(ws); i++ {
+		if !k.fits(ws[i], ws[i].waitMode, ws[:i]) {
+			return
+		}
+		wake(ws[i])
+	}
+}
+
+func wake(t *task) {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// quarantineKeys cancels the tasks of the group g with any of keys including the ones created later.
+func (lt *lockTable) quarantineKeys(g *Group, keys []string) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	sc := lt.scope(g)
+	if sc.quarantine == nil {
+		sc.quarantine = map[string]struct{}{}
+	}
+	for _, key := range keys {
+		sc.quarantine[key] = struct{}{}
+	}
+	lt.eachTask(func(t *task) {
+		if t.owner == g && !t.canceled && !t.running && sc.quarantined(t.keys) {
+			lt.cancelTask(t, ErrQuarantined)
+		}
+	})
+}
+
+func (sc *scope) quarantined(keys []string) bool {
+	for _, key := range keys {
+		if _, ok := sc.quarantine[key]; ok {
+			return true
+		}
+	}
+	return false
+}
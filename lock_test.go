@@ -0,0 +1,108 @@
+package concgroup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestKeyLocksCollected(t *testing.T) {
+	cg := new(Group)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("file-%d", i)
+		cg.GoMulti([]string{key, "shared"}, func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	cg.locks.mu.Lock()
+	defer cg.locks.mu.Unlock()
+	for i := range cg.locks.stripes {
+		s := &cg.locks.stripes[i]
+		if n := len(s.locks); n != 0 {
+			t.Errorf("got %d key locks left in stripe %d, want 0", n, i)
+		}
+		if n := len(s.refs); n != 0 {
+			t.Errorf("got %d key references left in stripe %d, want 0", n, i)
+		}
+		if n := len(s.tasks); n != 0 {
+			t.Errorf("got %d tasks left in stripe %d, want 0", n, i)
+		}
+	}
+}
+
+func TestPatternLimitersCollected(t *testing.T) {
+	cg := new(Group)
+	cg.SetLimitPattern("tenant/*", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cg.Go("tenant/0/job", func() error {
+		<-ctx.Done()
+		return nil
+	})
+	for i := 0; i < 100; i++ {
+		cg.Go(fmt.Sprintf("tenant/%d/job", i%10), func() error { return nil })
+	}
+	cancel()
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	cg.resources.mu.Lock()
+	defer cg.resources.mu.Unlock()
+	if n := len(cg.resources.limits); n != 0 {
+		t.Errorf("got %d limiters left, want 0", n)
+	}
+	if n := len(cg.resources.refs); n != 0 {
+		t.Errorf("got %d limiter references left, want 0", n)
+	}
+}
+
+func TestStripesOf(t *testing.T) {
+	lt := newLockTable()
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h", "a"}
+	ss := lt.stripesOf(keys)
+	seen := map[*stripe]bool{}
+	for _, key := range keys {
+		seen[lt.stripe(key)] = true
+	}
+	if len(ss) != len(seen) {
+		t.Errorf("got %d stripes, want %d", len(ss), len(seen))
+	}
+	for i := 1; i < len(ss); i++ {
+		if indexOf(lt, ss[i-1]) >= indexOf(lt, ss[i]) {
+			t.Errorf("got stripes not in index order")
+		}
+	}
+}
+
+func indexOf(lt *lockTable, s *stripe) int {
+	for i := range lt.stripes {
+		if &lt.stripes[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func BenchmarkGoParallel(b *testing.B) {
+	for _, keys := range []int{1, 2} {
+		b.Run(fmt.Sprintf("keys=%d", keys), func(b *testing.B) {
+			cg := new(Group)
+			var n atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := n.Add(1)
+					ks := make([]string, keys)
+					for j := range ks {
+						ks[j] = strconv.FormatInt(i+int64(j), 10)
+					}
+					cg.GoMulti(ks, func() error { return nil })
+				}
+			})
+			if err := cg.Wait(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package concgroup
+
+import (
+	"context"
+	"sync"
+)
+
+// KeyLocker is a backend of key locks, such as a lock service shared by processes.
+// Acquire blocks until it holds the locks of all keys or ctx is done, and returns the function releasing them.
+// keys are sorted and have no duplicates, so that lockers taking the locks one by one in order do not deadlock.
+type KeyLocker interface {
+	Acquire(ctx context.Context, keys []string) (release func(), err error)
+}
+
+// SetKeyLocker makes every function with keys acquire the locks of its keys from l before it is called,
+// and release them after it returns. The in-memory key locks of the group are kept as the first stage,
+// so that the functions of the group wait for each other in the group (with its strategy and priorities)
+// and only one of them per key asks l at a time, and l coordinates them with the holders outside the group.
+// If Acquire returns an error, the function is not called and its result has the error.
+// nil removes the locker.
+func (g *Group) SetKeyLocker(l KeyLocker) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.locker = l
+}
+
+// SetLockerFactory makes every function with keys lock the sync.Locker returned by f for each of its keys like
+// SetKeyLocker, such as an instrumented, fair or in-house lock for specific keys, while the keys for which f returns nil
+// use the in-memory key locks only. f is called on every acquisition, so it should return the same Locker for a key.
+// The lockers are locked in the order of the keys and unlocked in reverse. Since Lock cannot be interrupted,
+// a function whose context is done while waiting for a Locker gives up, and the Locker is unlocked once it is locked.
+// It replaces the locker set by SetKeyLocker. nil removes the factory.
+func (g *Group) SetLockerFactory(f func(key string) sync.Locker) {
+	if f == nil {
+		g.SetKeyLocker(nil)
+		return
+	}
+	g.SetKeyLocker(lockerFactory(f))
+}
+
+// lockerFactory is a KeyLocker locking the sync.Locker of each key.
+type lockerFactory func(key string) sync.Locker
+
+func (f lockerFactory) Acquire(ctx context.Context, keys []string) (func(), error) {
+	var locked []sync.Locker
+	unlock := func() {
+		for i := len(locked) - 1; i >= 0; i-- {
+			locked[i].Unlock()
+		}
+	}
+	for _, key := range keys {
+		l := f(key)
+		if l == nil {
+			continue
+		}
+		if err := lockContext(ctx, l); err != nil {
+			unlock()
+			return nil, err
+		}
+		locked = append(locked, l)
+	}
+	return unlock, nil
+}
+
+// lockContext locks l, giving up when ctx is done. l is unlocked once it is locked after giving up.
+func lockContext(ctx context.Context, l sync.Locker) error {
+	if ctx.Done() == nil {
+		l.Lock()
+		return nil
+	}
+	locked := make(chan struct{})
+	go func() {
+		l.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-locked
+			l.Unlock()
+		}()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+type recordingLocker struct {
+	mu       sync.Mutex
+	held     map[string]bool
+	acquired [][]string
+	err      error
+}
+
+func (l *recordingLocker) Acquire(ctx context.Context, keys []string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return nil, l.err
+	}
+	for _, key := range keys {
+		if l.held[key] {
+			return nil, errors.New("already held: " + key)
+		}
+		l.held[key] = true
+	}
+	l.acquired = append(l.acquired, keys)
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, key := range keys {
+			delete(l.held, key)
+		}
+	}, nil
+}
+
+func TestSetKeyLocker(t *testing.T) {
+	l := &recordingLocker{held: map[string]bool{}}
+	cg := &concgroup.Group{}
+	cg.SetKeyLocker(l)
+	for i := 0; i < 10; i++ {
+		cg.GoMulti([]string{"b", "a", "b"}, func() error {
+			return nil
+		})
+		cg.Go("a", func() error {
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(l.acquired); got != 20 {
+		t.Errorf("got %d acquisitions, want %d", got, 20)
+	}
+	for _, keys := range l.acquired {
+		if len(keys) == 2 && (keys[0] != "a" || keys[1] != "b") {
+			t.Errorf("got %v, want sorted keys", keys)
+		}
+	}
+	if len(l.held) != 0 {
+		t.Errorf("got %v, want all released", l.held)
+	}
+}
+
+func TestSetKeyLockerError(t *testing.T) {
+	want := errors.New("unavailable")
+	cg := &concgroup.Group{}
+	cg.SetKeyLocker(&recordingLocker{err: want})
+	called := false
+	cg.Go("key", func() error {
+		called = true
+		return nil
+	})
+	if err := cg.Wait(); !errors.Is(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+	if called {
+		t.Error("the function was called without the lock")
+	}
+}
+
+type countingLocker struct {
+	sync.Mutex
+	locks atomic.Int32
+}
+
+func (l *countingLocker) Lock() {
+	l.Mutex.Lock()
+	l.locks.Add(1)
+}
+
+func TestSetLockerFactory(t *testing.T) {
+	hot := &countingLocker{}
+	cg := &concgroup.Group{}
+	cg.SetLockerFactory(func(key string) sync.Locker {
+		if key == "hot" {
+			return hot
+		}
+		return nil
+	})
+	for i := 0; i < 5; i++ {
+		cg.Go("hot", func() error {
+			return nil
+		})
+		cg.GoMulti([]string{"cold", "hot"}, func() error {
+			return nil
+		})
+		cg.Go("cold", func() error {
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := hot.locks.Load(); got != 10 {
+		t.Errorf("got %d locks, want %d", got, 10)
+	}
+}
+
+func TestSetLockerFactoryContext(t *testing.T) {
+	held := &sync.Mutex{}
+	held.Lock()
+	cg := &concgroup.Group{}
+	cg.SetLockerFactory(func(string) sync.Locker {
+		return held
+	})
+	called := false
+	task := cg.GoHandle("key", func() error {
+		called = true
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+	task.Cancel()
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if called || !errors.Is(task.Err(), concgroup.ErrCanceled) {
+		t.Errorf("got %v, want the function canceled while waiting for the locker", task.Err())
+	}
+	held.Unlock()
+}
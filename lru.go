@@ -0,0 +1,86 @@
+package concgroup
+
+import (
+	"container/list"
+)
+
+// lru is a map of per-key state holding the most recently used keys.
+// Evicted state is rebuilt lazily from scratch when the key is used again.
+type lru[V any] struct {
+	// size is the maximum number of keys. Zero is treated as defaultKeyCacheSize, and a negative value indicates no limit.
+	size  int
+	items map[string]*list.Element
+	order list.List
+}
+
+// defaultKeyCacheSize is the number of keys of the per-key state kept unless SetKeyCacheSize is called.
+const defaultKeyCacheSize = 10000
+
+type lruItem[V any] struct {
+	key   string
+	value V
+}
+
+// get returns the state of key, creating it with newValue if necessary, and marks it as the most recently used.
+func (c *lru[V]) get(key string, newValue func() V) V {
+	if c.items == nil {
+		c.items = map[string]*list.Element{}
+	}
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*lruItem[V]).value
+	}
+	v := newValue()
+	c.items[key] = c.order.PushFront(&lruItem[V]{key: key, value: v})
+	c.evict()
+	return v
+}
+
+// lookup returns the state of key if any, and marks it as the most recently used.
+func (c *lru[V]) lookup(key string) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruItem[V]).value, true
+}
+
+// remove forgets the state of key.
+func (c *lru[V]) remove(key string) {
+	if e, ok := c.items[key]; ok {
+		c.order.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+// setSize sets the maximum number of keys, evicting the least recently used ones beyond it.
+func (c *lru[V]) setSize(n int) {
+	c.size = n
+	c.evict()
+}
+
+func (c *lru[V]) evict() {
+	size := c.size
+	if size == 0 {
+		size = defaultKeyCacheSize
+	}
+	for size > 0 && c.order.Len() > size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.items, e.Value.(*lruItem[V]).key)
+	}
+}
+
+// each calls fn with the state of every key from the most recently used.
+func (c *lru[V]) each(fn func(key string, v V)) {
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		item := e.Value.(*lruItem[V])
+		fn(item.key, item.value)
+	}
+}
+
+func (c *lru[V]) len() int {
+	return c.order.Len()
+}
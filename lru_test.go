@@ -0,0 +1,72 @@
+package concgroup
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	c := lru[int]{size: 2}
+	n := 0
+	newValue := func() int {
+		n++
+		return n
+	}
+	if got := c.get("a", newValue); got != 1 {
+		t.Errorf("got %d, want 1", got)
+	}
+	c.get("b", newValue)
+	if got := c.get("a", newValue); got != 1 {
+		t.Errorf("got %d, want 1", got)
+	}
+	c.get("c", newValue) // evicts b
+	if got := c.len(); got != 2 {
+		t.Errorf("got %d keys, want 2", got)
+	}
+	if got := c.get("a", newValue); got != 1 {
+		t.Errorf("got %d, want 1", got)
+	}
+	if got := c.get("b", newValue); got != 4 {
+		t.Errorf("got %d, want rebuilt 4", got)
+	}
+	var keys []string
+	c.each(func(key string, _ int) { keys = append(keys, key) })
+	if len(keys) != 2 || keys[0] != "b" || keys[1] != "a" {
+		t.Errorf("got %v, want [b a]", keys)
+	}
+	c.setSize(1)
+	if got := c.len(); got != 1 {
+		t.Errorf("got %d keys, want 1", got)
+	}
+}
+
+func TestKeyStateBounded(t *testing.T) {
+	g := new(Group)
+	g.SetKeyBreaker(3, time.Minute)
+	g.SetKeyBackoff(time.Millisecond, time.Second, 0)
+	g.SetKeyCacheSize(10)
+	for i := 0; i < 100; i++ {
+		r := TaskResult{Keys: []string{fmt.Sprintf("key-%d", i)}, Err: errors.New("task error")}
+		g.breaker.record(r)
+		g.backoff.record(r)
+	}
+	if got := g.breaker.circuits.len(); got != 10 {
+		t.Errorf("got %d circuits, want 10", got)
+	}
+	if got := g.backoff.failures.len(); got != 10 {
+		t.Errorf("got %d failure counts, want 10", got)
+	}
+
+	var kr keyRates
+	kr.set("*", 1000, 1)
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		// The bucket of each key is full again 1ms later.
+		kr.reserve([]string{fmt.Sprintf("key-%d", i)}, now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if got := len(kr.buckets); got > minSweepAt {
+		t.Errorf("got %d buckets, want at most %d", got, minSweepAt)
+	}
+}
@@ -0,0 +1,61 @@
+package concgroup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RecoveredPanic is a panic recovered from a function called by the Group.
+type RecoveredPanic struct {
+	// Keys are the keys of the function.
+	Keys []string
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+// Error implements error.
+func (p *RecoveredPanic) Error() string {
+	return fmt.Sprintf("concgroup: panic in function with keys [%s]: %v", strings.Join(p.Keys, ", "), p.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (p *RecoveredPanic) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// SetPanicAsError sets whether a panic in a function is returned as an error. When it is on, a panic is recovered
+// as a *RecoveredPanic error with the keys, the value and the stack trace, and handled like an error returned by
+// the function: Wait returns it if it is the first error, and the context of a group made by WithContext is canceled.
+// Otherwise, Wait panics with the *RecoveredPanic of the first panic.
+func (g *Group) SetPanicAsError(on bool) {
+	g.panicAsError.Store(on)
+}
+
+// WaitAndRecover blocks until all function calls have returned like Wait, but returns the first panic recovered
+// from the functions instead of re-panicking. err is the first error returned by the functions, apart from the panic.
+func (g *Group) WaitAndRecover() (recovered *RecoveredPanic, err error) {
+	w := g.startWait()
+	<-w.done
+	return g.takePanic(), w.err
+}
+
+// recordPanic records p if it is the first panic.
+func (g *Group) recordPanic(p *RecoveredPanic) {
+	g.panicMu.Lock()
+	defer g.panicMu.Unlock()
+	if g.recovered == nil {
+		g.recovered = p
+	}
+}
+
+// takePanic returns the first recorded panic and clears it.
+func (g *Group) takePanic() *RecoveredPanic {
+	g.panicMu.Lock()
+	defer g.panicMu.Unlock()
+	p := g.recovered
+	g.recovered = nil
+	return p
+}
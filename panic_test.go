@@ -0,0 +1,95 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestWaitAndRecover(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	errTask := errors.New("task error")
+	cg.Go("panic", func() error {
+		panic("boom")
+	})
+	cg.Go("error", func() error {
+		return errTask
+	})
+	called := false
+	cg.Go("panic", func() error {
+		called = true
+		return nil
+	})
+	recovered, err := cg.WaitAndRecover()
+	if !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+	if recovered == nil {
+		t.Fatal("want recovered panic")
+	}
+	if recovered.Value != "boom" || recovered.Keys[0] != "panic" {
+		t.Errorf("got %+v", recovered)
+	}
+	if !strings.Contains(string(recovered.Stack), "panic_test.go") {
+		t.Errorf("got stack %s", recovered.Stack)
+	}
+	if !called {
+		t.Error("key lock is not released after panic")
+	}
+}
+
+func TestWaitRepanics(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.Go("panic", func() error {
+		panic("boom")
+	})
+	defer func() {
+		v := recover()
+		p, ok := v.(*concgroup.RecoveredPanic)
+		if !ok {
+			t.Fatalf("got %v, want *concgroup.RecoveredPanic", v)
+		}
+		if p.Value != "boom" {
+			t.Errorf("got %v, want boom", p.Value)
+		}
+	}()
+	_ = cg.Wait()
+}
+
+func TestPanicAsError(t *testing.T) {
+	t.Parallel()
+	cg, ctx := concgroup.WithContext(context.Background())
+	cg.SetPanicAsError(true)
+	errValue := errors.New("panic value")
+	started := make(chan struct{})
+	canceled := false
+	cg.Go("tenant-b", func() error {
+		close(started)
+		<-ctx.Done()
+		canceled = true
+		return nil
+	})
+	<-started
+	cg.Go("tenant-a", func() error {
+		panic(errValue)
+	})
+	err := cg.Wait()
+	var p *concgroup.RecoveredPanic
+	if !errors.As(err, &p) {
+		t.Fatalf("got %v, want *concgroup.RecoveredPanic", err)
+	}
+	if p.Keys[0] != "tenant-a" || len(p.Stack) == 0 {
+		t.Errorf("got %+v, want the keys and the stack", p)
+	}
+	if !errors.Is(err, errValue) {
+		t.Errorf("got %v, want to unwrap %v", err, errValue)
+	}
+	if !canceled {
+		t.Error("context is not canceled by the panic")
+	}
+}
@@ -0,0 +1,31 @@
+package concgroup
+
+import (
+	"strings"
+)
+
+// GoPath calls the given function in a new goroutine like Go with a path-style key such as "db/table1/partition3".
+// It locks the key exclusively and its ancestors ("db" and "db/table1") in IntentionExclusive mode,
+// so that the function conflicts with the functions of its ancestors and descendants, including the ones locking
+// an ancestor in Shared mode by GoShared, while the functions of sibling paths run at the same time.
+func (g *Group) GoPath(path string, f func() error) {
+	g.GoMultiAccess(pathAccesses(path), f)
+}
+
+// GoMultiPath calls the given function in a new goroutine like GoPath with multiple path-style keys.
+func (g *Group) GoMultiPath(paths []string, f func() error) {
+	var a []KeyAccess
+	for _, p := range paths {
+		a = append(a, pathAccesses(p)...)
+	}
+	g.GoMultiAccess(a, f)
+}
+
+// pathAccesses returns the accesses to lock path exclusively with the intention locks of its ancestors.
+func pathAccesses(path string) []KeyAccess {
+	a := []KeyAccess{{Key: path, Mode: Exclusive}}
+	for i := strings.LastIndex(path, "/"); i > 0; i = strings.LastIndex(path[:i], "/") {
+		a = append(a, KeyAccess{Key: path[:i], Mode: IntentionExclusive})
+	}
+	return a
+}
@@ -0,0 +1,123 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestGoPath(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var (
+		mu      sync.Mutex
+		running = map[string]bool{}
+	)
+	var siblings, maxSiblings int64
+	check := func(path string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for p := range running {
+			if p == path || strings.HasPrefix(p, path+"/") || strings.HasPrefix(path, p+"/") {
+				return fmt.Errorf("%s runs with %s", path, p)
+			}
+		}
+		running[path] = true
+		return nil
+	}
+	for i := 0; i < 20; i++ {
+		paths := []string{
+			fmt.Sprintf("db/table1/partition%d", i%4),
+			"db/table1",
+			fmt.Sprintf("db/table2/partition%d", i%4),
+			"db",
+		}
+		path := paths[i%len(paths)]
+		cg.GoPath(path, func() error {
+			if err := check(path); err != nil {
+				return err
+			}
+			defer func() {
+				mu.Lock()
+				defer mu.Unlock()
+				delete(running, path)
+			}()
+			if strings.Contains(path, "partition") {
+				n := atomic.AddInt64(&siblings, 1)
+				defer atomic.AddInt64(&siblings, -1)
+				for {
+					m := atomic.LoadInt64(&maxSiblings)
+					if n <= m || atomic.CompareAndSwapInt64(&maxSiblings, m, n) {
+						break
+					}
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxSiblings < 2 {
+		t.Errorf("got max %d partitions running, want siblings to run at the same time", maxSiblings)
+	}
+}
+
+func TestGoPathIntentionLocks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		reader func(cg *concgroup.Group, f func() error)
+		want   bool
+	}{
+		{"Shared ancestor", func(cg *concgroup.Group, f func() error) { cg.GoShared("db/table1", f) }, false},
+		{"Exclusive ancestor", func(cg *concgroup.Group, f func() error) { cg.Go("db", f) }, false},
+		{"IntentionShared ancestor", func(cg *concgroup.Group, f func() error) {
+			cg.GoMultiAccess([]concgroup.KeyAccess{
+				{Key: "db/table1", Mode: concgroup.IntentionShared},
+				{Key: "db/table1/partition1", Mode: concgroup.Shared},
+			}, f)
+		}, true},
+		{"IntentionExclusive ancestor", func(cg *concgroup.Group, f func() error) { cg.GoPath("db/table1/partition1", f) }, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cg := new(concgroup.Group)
+			started := make(chan struct{})
+			release := make(chan struct{})
+			cg.GoPath("db/table1/partition3", func() error {
+				close(started)
+				<-release
+				return nil
+			})
+			<-started
+			ran := make(chan struct{})
+			tt.reader(cg, func() error {
+				close(ran)
+				return nil
+			})
+			select {
+			case <-ran:
+				if !tt.want {
+					t.Error("ran at the same time as the writer of a descendant")
+				}
+			case <-time.After(50 * time.Millisecond):
+				if tt.want {
+					t.Error("did not run at the same time as the writer of a sibling")
+				}
+			}
+			close(release)
+			if err := cg.Wait(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
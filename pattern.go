@@ -0,0 +1,83 @@
+package concgroup
+
+import (
+	"path"
+	"strings"
+)
+
+// limitPattern is a limit set by SetLimitPattern.
+type limitPattern struct {
+	pattern string
+	n       int
+}
+
+// SetLimitPattern limits the number of running functions with keys matching pattern to n per matched prefix.
+// pattern is a glob of path.Match matched against the leading "/"-separated segments of a key,
+// and the functions whose keys have the same matched prefix share the limit. For example, with keys such as
+// "tenant/<id>/job/<n>", SetLimitPattern("tenant/*", 2) lets 2 functions of each tenant run at the same time,
+// and SetLimitPattern("backup", 1) lets 1 function with "backup" or a key under "backup/" run at the same time.
+// Patterns are evaluated when a function is submitted. A value less than 1 removes the limit of pattern.
+func (g *Group) SetLimitPattern(pattern string, n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, lp := range g.limitPatterns {
+		if lp.pattern != pattern {
+			continue
+		}
+		if n < 1 {
+			g.limitPatterns = append(g.limitPatterns[:i], g.limitPatterns[i+1:]...)
+		} else {
+			g.limitPatterns[i].n = n
+		}
+		return
+	}
+	if n >= 1 {
+		g.limitPatterns = append(g.limitPatterns, limitPattern{pattern: pattern, n: n})
+	}
+}
+
+// applyLimitPatterns adds the limiters of the patterns matching the keys of s to its resources. g.mu must be held.
+// The limiters are defined by resources.acquire and dropped when no function uses them.
+func (g *Group) applyLimitPatterns(s *spec) {
+	if len(g.limitPatterns) == 0 {
+		return
+	}
+	var resources map[string]int
+	for _, lp := range g.limitPatterns {
+		for _, key := range s.keys {
+			prefix, ok := matchPrefix(lp.pattern, key)
+			if !ok {
+				continue
+			}
+			// The NUL characters keep the name apart from the names of DefineLimiter.
+			name := "\x00" + lp.pattern + "\x00" + prefix
+			if resources == nil {
+				resources = make(map[string]int, len(s.resources)+1)
+				for k, v := range s.resources {
+					resources[k] = v
+				}
+				s.patterns = map[string]int{}
+			}
+			resources[name] = 1
+			s.patterns[name] = lp.n
+		}
+	}
+	if resources != nil {
+		s.resources = resources
+	}
+}
+
+// matchPrefix returns the leading segments of key matching pattern.
+func matchPrefix(pattern, key string) (string, bool) {
+	n := strings.Count(pattern, "/") + 1
+	segments := strings.SplitN(key, "/", n+1)
+	if len(segments) < n {
+		return "", false
+	}
+	prefix := strings.Join(segments[:n], "/")
+	ok, err := path.Match(pattern, prefix)
+	if err != nil || !ok {
+		return "", false
+	}
+	return prefix, true
+}
@@ -0,0 +1,69 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetLimitPattern(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetLimitPattern("tenant/*", 2)
+	cg.SetLimitPattern("backup", 1)
+	var (
+		mu         sync.Mutex
+		running    = map[string]int{}
+		maxRunning = map[string]int{}
+	)
+	enter := func(group string) func() {
+		mu.Lock()
+		defer mu.Unlock()
+		running[group]++
+		if running[group] > maxRunning[group] {
+			maxRunning[group] = running[group]
+		}
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			running[group]--
+		}
+	}
+	for i := 0; i < 8; i++ {
+		for _, key := range []string{
+			fmt.Sprintf("tenant/a/job/%d", i),
+			fmt.Sprintf("tenant/b/job/%d", i),
+			fmt.Sprintf("backup/%d", i),
+			fmt.Sprintf("other/%d", i),
+		} {
+			group := strings.Join(strings.SplitN(key, "/", 3)[:2], "/")
+			if strings.HasPrefix(key, "backup/") {
+				group = "backup"
+			}
+			if strings.HasPrefix(key, "other/") {
+				group = "other"
+			}
+			cg.Go(key, func() error {
+				defer enter(group)()
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			})
+		}
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"tenant/a": 2, "tenant/b": 2, "backup": 1}
+	for group, n := range want {
+		if maxRunning[group] != n {
+			t.Errorf("got max %d running functions of %s, want %d", maxRunning[group], group, n)
+		}
+	}
+	if maxRunning["other"] < 2 {
+		t.Errorf("got max %d running functions of other, want unlimited", maxRunning["other"])
+	}
+}
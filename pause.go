@@ -0,0 +1,34 @@
+package concgroup
+
+// PauseKey stops calling the functions with any of keys, such as to throttle a noisy tenant.
+// The functions keep waiting for the key locks while functions with other keys proceed, and running functions are
+// not affected. Wait waits for the paused functions, so resume them with ResumeKey or cancel them with CancelKeys.
+func (g *Group) PauseKey(keys ...string) {
+	g.init()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	if lt.paused == nil {
+		lt.paused = map[string]struct{}{}
+	}
+	for _, key := range keys {
+		lt.paused[key] = struct{}{}
+	}
+}
+
+// ResumeKey resumes calling the functions with any of keys paused by PauseKey.
+func (g *Group) ResumeKey(keys ...string) {
+	g.init()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := lt.paused[key]; !ok {
+			continue
+		}
+		delete(lt.paused, key)
+		if lt.refs(key) > 0 {
+			lt.notify(lt.lock(key))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestPauseKey(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.PauseKey("tenant-x")
+	var called int64
+	for i := 0; i < 3; i++ {
+		cg.Go("tenant-x", func() error {
+			atomic.AddInt64(&called, 1)
+			return nil
+		})
+	}
+	otherCalled := make(chan struct{})
+	cg.Go("tenant-y", func() error {
+		close(otherCalled)
+		return nil
+	})
+	select {
+	case <-otherCalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("function with other key is not called")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt64(&called); n != 0 {
+		t.Errorf("got %d calls of the paused key, want 0", n)
+	}
+	if !cg.TryGo("tenant-y", func() error { return nil }) {
+		t.Error("TryGo with other key failed")
+	}
+	cg.ResumeKey("tenant-x")
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if called != 3 {
+		t.Errorf("got %d calls, want 3", called)
+	}
+}
+
+func TestPauseKeyCanceled(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	ch := cg.Subscribe("tenant-x")
+	cg.PauseKey("tenant-x")
+	cg.Go("tenant-x", func() error {
+		t.Error("paused function is called")
+		return nil
+	})
+	cg.CancelKeys("tenant-x")
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	r := <-ch
+	if !r.Canceled || !errors.Is(r.Err, concgroup.ErrCanceled) {
+		t.Errorf("got %+v, want canceled", r)
+	}
+}
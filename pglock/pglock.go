@@ -0,0 +1,94 @@
+// Package pglock provides a concgroup.KeyLocker over PostgreSQL advisory locks, so that the functions of groups
+// in different processes with the same keys do not run at the same time.
+// Keys are hashed to advisory lock IDs and locked with pg_advisory_lock on a connection taken from the pool of
+// a *sql.DB, which is held while the function runs and returned to the pool when it returns.
+// It uses database/sql only, so any PostgreSQL driver works:
+//
+//	db, err := sql.Open("pgx", dsn)
+//	...
+//	cg.SetKeyLocker(pglock.New(db))
+package pglock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"sort"
+)
+
+// Locker is a concgroup.KeyLocker over PostgreSQL advisory locks.
+type Locker struct {
+	db    *sql.DB
+	keyID func(key string) int64
+}
+
+// New returns a new Locker using the connections of db.
+func New(db *sql.DB) *Locker {
+	return &Locker{db: db, keyID: KeyID}
+}
+
+// SetKeyID sets the function hashing a key to an advisory lock ID. The default is KeyID.
+// Keys with the same ID lock each other, which is harmless but serializes unrelated functions.
+// The settings must be made before the locker is used.
+func (l *Locker) SetKeyID(f func(key string) int64) {
+	l.keyID = f
+}
+
+// KeyID returns the 64-bit FNV-1a hash of key as an advisory lock ID.
+func KeyID(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+// Acquire takes a connection from the pool and locks the IDs of keys on it in ascending order,
+// so that processes locking overlapping keys do not deadlock. It gives up when ctx is done,
+// as far as the driver cancels the running query on the cancellation of its context.
+func (l *Locker) Acquire(ctx context.Context, keys []string) (func(), error) {
+	ids := l.ids(keys)
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", id); err != nil {
+			// The lock may have been taken even if the query was canceled, so release all the locks of the session
+			// before returning the connection to the pool.
+			l.close(conn)
+			return nil, err
+		}
+	}
+	return func() {
+		l.close(conn)
+	}, nil
+}
+
+// close releases the advisory locks of the session of conn and returns it to the pool.
+func (l *Locker) close(conn *sql.Conn) {
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock_all()"); err != nil {
+		// Discard the connection rather than returning it to the pool with the locks.
+		_ = conn.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+	}
+	_ = conn.Close()
+}
+
+// ids returns the sorted advisory lock IDs of keys without duplicates.
+func (l *Locker) ids(keys []string) []int64 {
+	ids := make([]int64, 0, len(keys))
+	seen := make(map[int64]struct{}, len(keys))
+	for _, key := range keys {
+		id := l.keyID(key)
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
@@ -0,0 +1,166 @@
+package pglock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+// fakeServer emulates the advisory locks of a PostgreSQL server for the connections of fakeDriver.
+type fakeServer struct {
+	mu      sync.Mutex
+	changed chan struct{}
+	locks   map[int64]*fakeConn
+}
+
+type fakeDriver struct {
+	server *fakeServer
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{server: d.server}, nil
+}
+
+type fakeConn struct {
+	server *fakeServer
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s := c.server
+	switch query {
+	case "SELECT pg_advisory_lock($1)":
+		id := args[0].Value.(int64)
+		for {
+			s.mu.Lock()
+			if holder, ok := s.locks[id]; !ok || holder == c {
+				s.locks[id] = c
+				s.mu.Unlock()
+				return driver.RowsAffected(1), nil
+			}
+			changed := s.changed
+			s.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-changed:
+			}
+		}
+	case "SELECT pg_advisory_unlock_all()":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for id, holder := range s.locks {
+			if holder == c {
+				delete(s.locks, id)
+			}
+		}
+		close(s.changed)
+		s.changed = make(chan struct{})
+		return driver.RowsAffected(1), nil
+	}
+	return nil, errors.New("unknown query: " + query)
+}
+
+var (
+	registerOnce sync.Once
+	server       = &fakeServer{changed: make(chan struct{}), locks: map[int64]*fakeConn{}}
+)
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	registerOnce.Do(func() {
+		sql.Register("pglockfake", &fakeDriver{server: server})
+	})
+	db, err := sql.Open("pglockfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestLocker(t *testing.T) {
+	// Two groups with their own pools share the server like two replicas of a service.
+	var running atomic.Int32
+	var overlapped atomic.Bool
+	var groups []*concgroup.Group
+	for i := 0; i < 2; i++ {
+		cg := &concgroup.Group{}
+		cg.SetKeyLocker(New(openDB(t)))
+		for j := 0; j < 5; j++ {
+			cg.GoMulti([]string{"a", "b"}, func() error {
+				if running.Add(1) > 1 {
+					overlapped.Store(true)
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+		}
+		groups = append(groups, cg)
+	}
+	for _, cg := range groups {
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if overlapped.Load() {
+		t.Error("functions with the same keys ran at the same time across groups")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.locks) != 0 {
+		t.Errorf("got %d locks, want all released", len(server.locks))
+	}
+}
+
+func TestLockerCancel(t *testing.T) {
+	l := New(openDB(t))
+	release, err := l.Acquire(context.Background(), []string{"key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := New(openDB(t)).Acquire(ctx, []string{"key"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestIDs(t *testing.T) {
+	l := New(nil)
+	l.SetKeyID(func(key string) int64 {
+		return int64(len(key))
+	})
+	got := l.ids([]string{"ccc", "a", "bb", "dd"})
+	want := []int64{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package pipeline provides keyed pipelines on top of concgroup.Group.
+//
+// A Pipeline passes each value pushed with a key through its stages in order, like the partitions of Kafka:
+// the values of a key go through every stage in the order they are pushed, one at a time per stage,
+// while different keys, and different stages of a key, run concurrently.
+//
+//	p := pipeline.New(cg, fetch, parse, store)
+//	for _, ev := range events {
+//		p.Push(ev.UserID, ev)
+//	}
+//	err := p.Wait()
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/k1LoW/concgroup"
+)
+
+// ErrSkip is returned by a stage to drop the value without passing it to the following stages or failing the group.
+var ErrSkip = errors.New("pipeline: skip")
+
+// Stage is a stage of a pipeline, transforming the value v of key for the next stage.
+type Stage[T any] func(ctx context.Context, key string, v T) (T, error)
+
+// Pipeline is a keyed pipeline.
+type Pipeline[T any] struct {
+	cg     *concgroup.Group
+	stages []Stage[T]
+}
+
+// New returns a new Pipeline running stages in cg. The output of the last stage is discarded.
+//
+// A stage of a value is called with the key lock StageKey(i, key) of the i-th stage, and the value is submitted to
+// the next stage before the lock is released, so that the order of the values of a key is kept across the stages
+// as long as cg calls the functions waiting for a key in the order of submission, which is the default strategy.
+// Since submitting to the next stage from a running function waits for the limit of cg, do not set a limit with SetLimit,
+// which may deadlock; bound the concurrency of a stage by SetLimitPattern with "stage<i>" instead.
+func New[T any](cg *concgroup.Group, stages ...Stage[T]) *Pipeline[T] {
+	return &Pipeline[T]{cg: cg, stages: stages}
+}
+
+// StageKey returns the key lock of key in the i-th stage (from 0), such as "stage0/user-1".
+func StageKey(i int, key string) string {
+	return "stage" + strconv.Itoa(i) + "/" + key
+}
+
+// Push pushes v of key to the first stage.
+func (p *Pipeline[T]) Push(key string, v T) {
+	p.submit(0, key, v)
+}
+
+// Wait waits for all the pushed values to go through the pipeline, and returns the first error like concgroup.Group.Wait.
+func (p *Pipeline[T]) Wait() error {
+	return p.cg.Wait()
+}
+
+// submit submits v of key to the i-th stage.
+func (p *Pipeline[T]) submit(i int, key string, v T) {
+	if i >= len(p.stages) {
+		return
+	}
+	p.cg.GoCtx(StageKey(i, key), func(ctx context.Context) error {
+		out, err := p.stages[i](ctx, key, v)
+		if err != nil {
+			if errors.Is(err, ErrSkip) {
+				return nil
+			}
+			return err
+		}
+		p.submit(i+1, key, out)
+		return nil
+	})
+}
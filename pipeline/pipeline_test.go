@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func jitter() {
+	time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+}
+
+func TestPipeline(t *testing.T) {
+	cg := &concgroup.Group{}
+	var mu sync.Mutex
+	got := map[string][]int{}
+	p := New(cg,
+		func(_ context.Context, _ string, v int) (int, error) {
+			jitter()
+			return v * 10, nil
+		},
+		func(_ context.Context, _ string, v int) (int, error) {
+			jitter()
+			if v == 30 {
+				return 0, ErrSkip
+			}
+			return v + 1, nil
+		},
+		func(_ context.Context, key string, v int) (int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			got[key] = append(got[key], v)
+			return v, nil
+		},
+	)
+	for i := 0; i < 6; i++ {
+		p.Push("a", i)
+		p.Push("b", i)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 11, 21, 41, 51}
+	for _, key := range []string{"a", "b"} {
+		if fmt.Sprint(got[key]) != fmt.Sprint(want) {
+			t.Errorf("got %v, want %v for %s", got[key], want, key)
+		}
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	cg, _ := concgroup.WithContext(context.Background())
+	failed := errors.New("failed")
+	var mu sync.Mutex
+	var reached []int
+	p := New(cg,
+		func(_ context.Context, _ string, v int) (int, error) {
+			if v == 1 {
+				return 0, failed
+			}
+			return v, nil
+		},
+		func(ctx context.Context, _ string, v int) (int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reached = append(reached, v)
+			return v, nil
+		},
+	)
+	p.Push("key", 0)
+	p.Push("key", 1)
+	if err := p.Wait(); !errors.Is(err, failed) {
+		t.Errorf("got %v, want %v", err, failed)
+	}
+	for _, v := range reached {
+		if v == 1 {
+			t.Error("the failed value reached the next stage")
+		}
+	}
+}
+
+func TestStageKey(t *testing.T) {
+	if got, want := StageKey(2, "user-1"), "stage2/user-1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package concgroup
+
+import (
+	"strconv"
+)
+
+// SetKeyHashing makes the group serialize functions by the hash of the key into m fixed locks instead of a lock per key,
+// bounding the memory of the key locks to O(m) for extremely high key cardinality.
+// Functions with different keys of the same hash are also serialized (false conflicts).
+// Zero or a negative value restores a lock per key.
+// It must not be called while goroutines in the group are active.
+func (g *Group) SetKeyHashing(m int) {
+	g.init()
+	g.locks.mu.Lock()
+	defer g.locks.mu.Unlock()
+	for i := range g.locks.stripes {
+		g.locks.stripes[i].locks = map[string]*keyLock{}
+	}
+	g.locks.pool = nil
+	if m <= 0 {
+		return
+	}
+	g.locks.pool = make([]*keyLock, m)
+	for i := range g.locks.pool {
+		// The locks in the pool are named by index.
+		g.locks.pool[i] = &keyLock{key: "#" + strconv.Itoa(i)}
+	}
+	for key, n := range g.locks.keyLimits {
+		g.locks.lock(key).limit = n
+	}
+}
+
+// hashKey returns the FNV-1a hash of key.
+func hashKey(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime64
+	}
+	return h
+}
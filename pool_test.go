@@ -0,0 +1,87 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestKeyHashing(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetKeyHashing(1)
+	var running int64
+	for i := 0; i < 5; i++ {
+		cg.GoMulti([]string{fmt.Sprintf("a-%d", i), fmt.Sprintf("b-%d", i)}, func() error {
+			if atomic.AddInt64(&running, 1) > 1 {
+				return fmt.Errorf("functions with keys of the same hash run at the same time")
+			}
+			defer atomic.AddInt64(&running, -1)
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyHashingSameKey(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetKeyHashing(64)
+	for _, strategy := range []concgroup.Strategy{concgroup.SortedOrder, concgroup.AllOrNothing, concgroup.WaitDie, concgroup.WoundWait} {
+		cg.SetStrategy(strategy)
+		mu := make(map[string]*atomic.Bool)
+		for i := 0; i < 8; i++ {
+			mu[fmt.Sprintf("key-%d", i)] = new(atomic.Bool)
+		}
+		for i := 0; i < 50; i++ {
+			k1, k2 := fmt.Sprintf("key-%d", i%8), fmt.Sprintf("key-%d", (i*3+1)%8)
+			cg.GoMulti([]string{k1, k2}, func() error {
+				for _, k := range []string{k1, k2} {
+					if k1 == k2 && k == k2 {
+						break
+					}
+					if !mu[k].CompareAndSwap(false, true) {
+						return fmt.Errorf("violate %s concurrency", k)
+					}
+				}
+				time.Sleep(time.Millisecond)
+				mu[k1].Store(false)
+				mu[k2].Store(false)
+				return nil
+			})
+		}
+		if err := cg.Wait(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestKeyHashingLockOrder(t *testing.T) {
+	t.Parallel()
+	for _, strategy := range []concgroup.Strategy{concgroup.SortedOrder, concgroup.AllOrNothing, concgroup.WaitDie, concgroup.WoundWait} {
+		cg := new(concgroup.Group)
+		cg.SetKeyHashing(2)
+		cg.SetStrategy(strategy)
+		// The sorted keys of the two functions hash to the locks in opposite orders.
+		for i := 0; i < 200; i++ {
+			cg.GoMulti([]string{"k01", "k02"}, func() error { return nil })
+			cg.GoMulti([]string{"k00", "k01"}, func() error { return nil })
+		}
+		done := make(chan error)
+		go func() { done <- cg.Wait() }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("deadlocked with %T", strategy)
+		}
+	}
+}
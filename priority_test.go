@@ -0,0 +1,82 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("samegroup", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	mu := sync.Mutex{}
+	var got []string
+	// Functions are queued for the key lock at submission, so no sleep is needed before unblocking.
+	for i, p := range []int{1, 3, 2, 3} {
+		name := fmt.Sprintf("%d-%d", p, i)
+		cg.GoPriority("samegroup", p, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, name)
+			return nil
+		})
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"3-1", "3-3", "2-2", "1-0"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPriorityInheritance(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("B", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	mu := sync.Mutex{}
+	var got []string
+	record := func(name string) func() error {
+		return func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, name)
+			return nil
+		}
+	}
+	// low holds A and waits for B.
+	cg.GoMultiPriority([]string{"A", "B"}, 0, record("low"))
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		cg.GoPriority("B", 5, record("middle"))
+	}
+	// high waits for A held by low, so low inherits the priority of high.
+	cg.GoPriority("A", 10, record("high"))
+	time.Sleep(100 * time.Millisecond)
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != "low" {
+		t.Errorf("got %v, want low first", got)
+	}
+}
@@ -0,0 +1,183 @@
+package concgroup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress is the progress of the functions submitted to the group.
+type Progress struct {
+	// Done is the number of functions returned or canceled.
+	Done int
+	// Total is the number of functions submitted so far.
+	Total int
+	// Keys is the progress per key.
+	Keys map[string]KeyProgress
+}
+
+// KeyProgress is the progress of the functions with a key.
+type KeyProgress struct {
+	// Key is the key.
+	Key string
+	// Done is the number of functions returned or canceled.
+	Done int
+	// Total is the number of functions submitted so far.
+	Total int
+}
+
+// Pending returns the number of functions not done yet.
+func (p KeyProgress) Pending() int {
+	return p.Total - p.Done
+}
+
+// String returns a line such as "processed 1234/5000 (go: 12 pending, google: 3 pending)",
+// listing the keys with pending functions in descending order of the number.
+func (p Progress) String() string {
+	pending := make([]KeyProgress, 0, len(p.Keys))
+	for _, kp := range p.Keys {
+		if kp.Pending() > 0 {
+			pending = append(pending, kp)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Pending() != pending[j].Pending() {
+			return pending[i].Pending() > pending[j].Pending()
+		}
+		return pending[i].Key < pending[j].Key
+	})
+	s := fmt.Sprintf("processed %d/%d", p.Done, p.Total)
+	if len(pending) == 0 {
+		return s
+	}
+	keys := make([]string, 0, len(pending))
+	for _, kp := range pending {
+		keys = append(keys, fmt.Sprintf("%s: %d pending", kp.Key, kp.Pending()))
+	}
+	return s + " (" + strings.Join(keys, ", ") + ")"
+}
+
+// ProgressEvent is an event of the lifecycle of a function with the progress of the group at the event.
+type ProgressEvent struct {
+	Event
+	// Done is the number of functions returned or canceled so far, including the function of a finished event.
+	Done int
+	// Total is the number of functions submitted so far, including the function of a submitted event.
+	Total int
+}
+
+// Progress returns a channel that receives the events of the functions as they are submitted, started and finished,
+// with the numbers of functions done and submitted so far, such as to render a progress bar of a batch.
+// The channel is closed when Wait returns. Events are buffered, so the channel should be drained until it is closed.
+func (g *Group) Progress() <-chan ProgressEvent {
+	return g.subs.addProgress()
+}
+
+// OnProgress registers fn to be called with the numbers of functions done and submitted so far every time a function
+// returns or is canceled, such as to print "37/120 tables done". The calls are serialized in the order of the numbers.
+// fn is called by the goroutine of a function before Wait returns, so it should return quickly. fn may use the group:
+// the calls caused by fn are made after fn returns.
+func (g *Group) OnProgress(fn func(done, total int)) {
+	g.subs.addOnProgress(fn)
+}
+
+// ProgressWriter receives the progress of the group, such as a terminal progress bar.
+type ProgressWriter interface {
+	WriteProgress(p Progress)
+}
+
+// ProgressWriterFunc is a function implementing ProgressWriter.
+type ProgressWriterFunc func(p Progress)
+
+// WriteProgress calls f(p).
+func (f ProgressWriterFunc) WriteProgress(p Progress) {
+	f(p)
+}
+
+// SetProgressWriter makes the group write the progress to w as functions are submitted and finished,
+// at most once per interval, and once more when Wait returns.
+func (g *Group) SetProgressWriter(w ProgressWriter, interval time.Duration) {
+	g.progress.mu.Lock()
+	g.progress.w = w
+	g.progress.interval = interval
+	g.progress.mu.Unlock()
+}
+
+// progress tracks Progress from the events of the group,
+// which is also the numbers of functions done and submitted for ProgressEvent and OnProgress.
+type progress struct {
+	mu       sync.Mutex
+	w        ProgressWriter
+	interval time.Duration
+	last     time.Time
+	p        Progress
+	// writeMu serializes writes to w in order.
+	writeMu sync.Mutex
+}
+
+// record counts e, publishes it with the counts to subs in order, and writes the progress to w at most once per interval.
+func (p *progress) record(e Event, subs *subscriptions) {
+	p.mu.Lock()
+	counted := true
+	switch e.Kind {
+	case EventSubmitted:
+		p.p.Total++
+		p.add(e.Keys, 0, 1)
+	case EventFinished:
+		p.p.Done++
+		p.add(e.Keys, 1, 0)
+	default:
+		counted = false
+	}
+	deliver := subs.publishProgress(ProgressEvent{Event: e, Done: p.p.Done, Total: p.p.Total})
+	write := counted && p.w != nil && e.Time.Sub(p.last) >= p.interval
+	if write {
+		p.last = e.Time
+	}
+	p.mu.Unlock()
+	if deliver {
+		// Call the functions of OnProgress without p.mu so that they can use the group.
+		subs.deliver()
+	}
+	if write {
+		p.write()
+	}
+}
+
+// add counts the progress of keys, only while the progress is written to w.
+func (p *progress) add(keys []string, done, total int) {
+	if p.w == nil {
+		return
+	}
+	if p.p.Keys == nil {
+		p.p.Keys = map[string]KeyProgress{}
+	}
+	for _, key := range keys {
+		kp := p.p.Keys[key]
+		kp.Key = key
+		kp.Done += done
+		kp.Total += total
+		p.p.Keys[key] = kp
+	}
+}
+
+// write writes the current progress to w.
+func (p *progress) write() {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	p.mu.Lock()
+	w := p.w
+	if w == nil {
+		p.mu.Unlock()
+		return
+	}
+	cp := p.p
+	cp.Keys = make(map[string]KeyProgress, len(p.p.Keys))
+	for key, kp := range p.p.Keys {
+		cp.Keys[key] = kp
+	}
+	p.mu.Unlock()
+	w.WriteProgress(cp)
+}
@@ -0,0 +1,188 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestProgressWriter(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var (
+		mu       sync.Mutex
+		progress []concgroup.Progress
+	)
+	cg.SetProgressWriter(concgroup.ProgressWriterFunc(func(p concgroup.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress = append(progress, p)
+	}), time.Hour)
+	for i := 0; i < 10; i++ {
+		key := "go"
+		if i%3 == 0 {
+			key = "google"
+		}
+		cg.Go(key, func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	// The first event and the completion of Wait are written within the interval.
+	if len(progress) != 2 {
+		t.Fatalf("got %d writes, want 2", len(progress))
+	}
+	last := progress[len(progress)-1]
+	if last.Done != 10 || last.Total != 10 {
+		t.Errorf("got %d/%d, want 10/10", last.Done, last.Total)
+	}
+	if got := last.Keys["google"]; got.Done != 4 || got.Total != 4 {
+		t.Errorf("got %+v, want 4/4", got)
+	}
+	if got, want := last.String(), "processed 10/10"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestProgressString(t *testing.T) {
+	p := concgroup.Progress{
+		Done:  1234,
+		Total: 5000,
+		Keys: map[string]concgroup.KeyProgress{
+			"go":     {Key: "go", Done: 10, Total: 22},
+			"google": {Key: "google", Done: 5, Total: 8},
+			"done":   {Key: "done", Done: 3, Total: 3},
+		},
+	}
+	if got, want := p.String(), "processed 1234/5000 (go: 12 pending, google: 3 pending)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	ch := cg.Progress()
+	for i := 0; i < 5; i++ {
+		cg.Go("key", func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[concgroup.EventKind]int{}
+	var last concgroup.ProgressEvent
+	for e := range ch {
+		kinds[e.Kind]++
+		if e.Keys[0] != "key" || e.Time.IsZero() {
+			t.Errorf("got %+v, want an event of key with the time", e)
+		}
+		if e.Done < last.Done || e.Total < last.Total || e.Done > e.Total {
+			t.Errorf("got %d/%d after %d/%d, want growing progress", e.Done, e.Total, last.Done, last.Total)
+		}
+		last = e
+	}
+	for _, k := range []concgroup.EventKind{concgroup.EventSubmitted, concgroup.EventStarted, concgroup.EventFinished} {
+		if kinds[k] != 5 {
+			t.Errorf("got %d %s events, want 5", kinds[k], k)
+		}
+	}
+	if last.Done != 5 || last.Total != 5 {
+		t.Errorf("got %d/%d, want 5/5", last.Done, last.Total)
+	}
+}
+
+func TestOnProgress(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var got []int
+	last := 0
+	cg.OnProgress(func(done, total int) {
+		if total < last || total < done || total > 120 {
+			t.Errorf("got total %d after %d with %d done, want growing total of submissions", total, last, done)
+		}
+		last = total
+		got = append(got, done)
+	})
+	release := make(chan struct{})
+	for i := 0; i < 120; i++ {
+		cg.Go(fmt.Sprintf("table-%d", i%7), func() error {
+			<-release
+			return nil
+		})
+	}
+	close(release)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 120 {
+		t.Fatalf("got %d calls, want 120", len(got))
+	}
+	if last != 120 {
+		t.Errorf("got total %d, want 120", last)
+	}
+	for i, done := range got {
+		if done != i+1 {
+			t.Fatalf("got %d done at call %d, want %d", done, i, i+1)
+		}
+	}
+}
+
+func TestOnProgressReentrant(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var got []string
+	cg.OnProgress(func(done, total int) {
+		got = append(got, fmt.Sprintf("%d/%d", done, total))
+		if done == 1 {
+			// Rejected right away by the closed group, finishing within this call.
+			cg.Go("b", func() error { return nil })
+		}
+	})
+	cg.Go("a", func() error { return nil })
+	cg.Close()
+	done := make(chan error)
+	go func() { done <- cg.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("deadlocked calling the group from OnProgress")
+	}
+	if want := "[1/1 2/2]"; fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func TestProgressCountsAgree(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var last string
+	finished := make(chan struct{}, 4)
+	cg.OnProgress(func(done, total int) {
+		last = fmt.Sprintf("%d/%d", done, total)
+		finished <- struct{}{}
+	})
+	for i := 0; i < 3; i++ {
+		cg.Go("samegroup", func() error { return nil })
+	}
+	for i := 0; i < 3; i++ {
+		<-finished
+	}
+	// The progress written includes the functions finished before the writer is set.
+	var written concgroup.Progress
+	cg.SetProgressWriter(concgroup.ProgressWriterFunc(func(p concgroup.Progress) {
+		written = p
+	}), time.Hour)
+	cg.Go("samegroup", func() error { return nil })
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%d/%d", written.Done, written.Total); got != last || got != "4/4" {
+		t.Errorf("got written %s and OnProgress %s, want 4/4", got, last)
+	}
+}
@@ -0,0 +1,120 @@
+// Package prometheus provides a prometheus.Collector of the metrics of concgroup.Group for client_golang.
+// It is a module of its own, so the core stays free of the Prometheus client library.
+//
+//	c := prometheus.New()
+//	cg.AddEventSink(c)
+//	reg.MustRegister(c)
+//
+// It collects the following metrics:
+//
+//	concgroup_running_tasks               gauge   functions running now
+//	concgroup_pending_tasks{key}          gauge   functions submitted but not called yet, per key
+//	concgroup_tasks_completed_total       counter functions returned or canceled
+//	concgroup_tasks_errored_total         counter functions returned or canceled with an error
+//	concgroup_lock_wait_seconds           summary time from submission to call waiting for the key locks and the limits
+package prometheus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	runningDesc   = prometheus.NewDesc("concgroup_running_tasks", "Number of functions running now.", nil, nil)
+	pendingDesc   = prometheus.NewDesc("concgroup_pending_tasks", "Number of functions submitted but not called yet.", []string{"key"}, nil)
+	completedDesc = prometheus.NewDesc("concgroup_tasks_completed_total", "Number of functions returned or canceled.", nil, nil)
+	erroredDesc   = prometheus.NewDesc("concgroup_tasks_errored_total", "Number of functions returned or canceled with an error.", nil, nil)
+	waitDesc      = prometheus.NewDesc("concgroup_lock_wait_seconds", "Time from submission to call of functions.", nil, nil)
+)
+
+// Collector collects the metrics of a group from its events as a prometheus.Collector.
+type Collector struct {
+	mu        sync.Mutex
+	submitted map[uint64]time.Time
+	started   map[uint64]struct{}
+	pending   map[string]int
+	completed uint64
+	errored   uint64
+	waitSum   time.Duration
+	waitCount uint64
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New returns a new Collector. Register it to a group with AddEventSink, and to a prometheus.Registerer.
+func New() *Collector {
+	return &Collector{
+		submitted: map[uint64]time.Time{},
+		started:   map[uint64]struct{}{},
+		pending:   map[string]int{},
+	}
+}
+
+// Record implements concgroup.EventSink.
+func (c *Collector) Record(ev concgroup.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch ev.Kind {
+	case concgroup.EventSubmitted:
+		if ev.Seq == 0 {
+			// A function rejected at submission is never pending.
+			return
+		}
+		c.submitted[ev.Seq] = ev.Time
+		c.addPending(ev.Keys, 1)
+	case concgroup.EventStarted:
+		if at, ok := c.submitted[ev.Seq]; ok {
+			delete(c.submitted, ev.Seq)
+			c.started[ev.Seq] = struct{}{}
+			c.addPending(ev.Keys, -1)
+			c.waitSum += ev.Time.Sub(at)
+			c.waitCount++
+		}
+	case concgroup.EventFinished:
+		if _, ok := c.submitted[ev.Seq]; ok {
+			// The function is canceled without being called.
+			delete(c.submitted, ev.Seq)
+			c.addPending(ev.Keys, -1)
+		}
+		delete(c.started, ev.Seq)
+		c.completed++
+		if ev.Result.Err != nil {
+			c.errored++
+		}
+	}
+}
+
+// addPending adds n to the number of pending functions with keys, forgetting the keys with none.
+func (c *Collector) addPending(keys []string, n int) {
+	for _, key := range keys {
+		c.pending[key] += n
+		if c.pending[key] <= 0 {
+			delete(c.pending, key)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- runningDesc
+	ch <- pendingDesc
+	ch <- completedDesc
+	ch <- erroredDesc
+	ch <- waitDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(runningDesc, prometheus.GaugeValue, float64(len(c.started)))
+	for key, n := range c.pending {
+		ch <- prometheus.MustNewConstMetric(pendingDesc, prometheus.GaugeValue, float64(n), key)
+	}
+	ch <- prometheus.MustNewConstMetric(completedDesc, prometheus.CounterValue, float64(c.completed))
+	ch <- prometheus.MustNewConstMetric(erroredDesc, prometheus.CounterValue, float64(c.errored))
+	ch <- prometheus.MustNewConstSummary(waitDesc, c.waitCount, c.waitSum.Seconds(), nil)
+}
@@ -0,0 +1,99 @@
+package prometheus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	cg := new(concgroup.Group)
+	c := New()
+	cg.AddEventSink(c)
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("tenant-a", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	cg.Go("tenant-a", func() error {
+		return errors.New("failed")
+	})
+	cg.Go(`tenant-"b"`, func() error {
+		<-block
+		return nil
+	})
+	// Wait until the function with tenant-"b" is called.
+	for value(t, reg, "concgroup_running_tasks") != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP concgroup_pending_tasks Number of functions submitted but not called yet.
+# TYPE concgroup_pending_tasks gauge
+concgroup_pending_tasks{key="tenant-a"} 1
+# HELP concgroup_tasks_completed_total Number of functions returned or canceled.
+# TYPE concgroup_tasks_completed_total counter
+concgroup_tasks_completed_total 0
+`), "concgroup_pending_tasks", "concgroup_tasks_completed_total"); err != nil {
+		t.Error(err)
+	}
+	close(block)
+	if err := cg.Wait(); err == nil {
+		t.Fatal("want error")
+	}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP concgroup_running_tasks Number of functions running now.
+# TYPE concgroup_running_tasks gauge
+concgroup_running_tasks 0
+# HELP concgroup_tasks_completed_total Number of functions returned or canceled.
+# TYPE concgroup_tasks_completed_total counter
+concgroup_tasks_completed_total 3
+# HELP concgroup_tasks_errored_total Number of functions returned or canceled with an error.
+# TYPE concgroup_tasks_errored_total counter
+concgroup_tasks_errored_total 1
+`), "concgroup_pending_tasks", "concgroup_running_tasks", "concgroup_tasks_completed_total", "concgroup_tasks_errored_total"); err != nil {
+		t.Error(err)
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() == "concgroup_lock_wait_seconds" {
+			if got := mf.GetMetric()[0].GetSummary().GetSampleCount(); got != 3 {
+				t.Errorf("got %d lock waits, want 3", got)
+			}
+		}
+	}
+}
+
+// value returns the value of the gauge or counter named name gathered by reg.
+func value(t *testing.T, reg prometheus.Gatherer, name string) float64 {
+	t.Helper()
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		m := mf.GetMetric()[0]
+		if g := m.GetGauge(); g != nil {
+			return g.GetValue()
+		}
+		return m.GetCounter().GetValue()
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package concgroup
+
+import "errors"
+
+// ErrQueueFull is the error of a function rejected by QueueFullReject.
+var ErrQueueFull = errors.New("concgroup: queue full")
+
+// QueueFullPolicy is the policy for a function with a key whose queue is full by SetMaxPending.
+type QueueFullPolicy int
+
+const (
+	// QueueFullBlock blocks the submission until the queues of the keys have room.
+	// TryGo and TryGoMulti return false instead.
+	QueueFullBlock QueueFullPolicy = iota
+	// QueueFullReject rejects the function: it is not called, and fails with ErrQueueFull.
+	QueueFullReject
+)
+
+// SetMaxPending limits the number of pending functions waiting for the lock of each key to at most n,
+// so that a hot key does not accumulate an unbounded number of parked goroutines.
+// A function with a key whose queue is full is handled by p.
+// A negative value indicates no limit.
+func (g *Group) SetMaxPending(n int, p QueueFullPolicy) {
+	g.init()
+	g.locks.mu.Lock()
+	defer g.locks.mu.Unlock()
+	g.locks.maxPending = n
+	g.locks.queueFull = p
+	g.locks.keyFreed.Broadcast()
+}
+
+// full reports whether the queue of any of keys is full. lt.mu must be held.
+func (lt *lockTable) full(keys []string) bool {
+	if lt.maxPending < 0 {
+		return false
+	}
+	for _, key := range keys {
+		s := lt.stripe(key)
+		refs := s.refs[key]
+		if refs == 0 {
+			continue
+		}
+		queued := refs - s.running[key]
+		if s.running[key] == 0 {
+			// One of the pending functions is about to run.
+			queued--
+		}
+		if queued >= lt.maxPending {
+			return true
+		}
+	}
+	return false
+}
+
+// started counts t as running, making room in the queues of its keys. lt.mu must be held.
+func (lt *lockTable) started(t *task) {
+	t.running = true
+	lt.runningTasks.Add(1)
+	for _, key := range t.keys {
+		lt.stripe(key).running[key]++
+	}
+	if lt.maxPending >= 0 {
+		lt.keyFreed.Broadcast()
+	}
+}
+
+// stopped counts t as no longer running. lt.mu must be held.
+func (lt *lockTable) stopped(t *task) {
+	t.running = false
+	lt.runningTasks.Add(-1)
+	for _, key := range t.keys {
+		s := lt.stripe(key)
+		s.running[key]--
+		if s.running[key] == 0 {
+			delete(s.running, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestMaxPendingReject(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetMaxPending(1, concgroup.QueueFullReject)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	var called int64
+	cg.Go("hot", func() error {
+		close(started)
+		<-block
+		atomic.AddInt64(&called, 1)
+		return nil
+	})
+	<-started
+	for i := 0; i < 3; i++ {
+		cg.Go("hot", func() error {
+			atomic.AddInt64(&called, 1)
+			return nil
+		})
+	}
+	cg.Go("cold", func() error {
+		atomic.AddInt64(&called, 1)
+		return nil
+	})
+	close(block)
+	if err := cg.Wait(); !errors.Is(err, concgroup.ErrQueueFull) {
+		t.Errorf("got %v, want %v", err, concgroup.ErrQueueFull)
+	}
+	if called != 3 {
+		t.Errorf("got %d calls, want 3", called)
+	}
+}
+
+func TestMaxPendingBlock(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetMaxPending(0, concgroup.QueueFullBlock)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("hot", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	if cg.TryGo("hot", func() error { return nil }) {
+		t.Error("TryGo with a full queue succeeded")
+	}
+	submitted := make(chan struct{})
+	go func() {
+		cg.Go("hot", func() error { return nil })
+		close(submitted)
+	}()
+	select {
+	case <-submitted:
+		t.Error("Go with a full queue did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(block)
+	<-submitted
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,156 @@
+package concgroup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SetKeyRate paces the functions with keys matching pattern to at most r calls per second per matched prefix,
+// allowing bursts of up to burst calls, with a token bucket. pattern is matched like SetLimitPattern: for example,
+// with host names as keys, SetKeyRate("*", 2, 1) calls at most 2 functions per second for each host.
+// The functions wait for their turn while holding the key locks. A zero or negative r removes the rate of pattern.
+func (g *Group) SetKeyRate(pattern string, r float64, burst int) {
+	g.rates.set(pattern, r, burst)
+}
+
+// keyRates paces the functions with keys matching the patterns set by SetKeyRate.
+type keyRates struct {
+	mu       sync.Mutex
+	patterns []ratePattern
+	// buckets is the token buckets of the matched prefixes of each pattern.
+	// The full ones, which are the same as new ones, are dropped once the map doubles since the last sweep.
+	buckets map[ratePrefix]*bucket
+	sweepAt int
+}
+
+// minSweepAt is the number of buckets to keep without sweeping.
+const minSweepAt = 64
+
+type ratePattern struct {
+	pattern string
+	r       float64
+	burst   int
+}
+
+type ratePrefix struct {
+	pattern string
+	prefix  string
+}
+
+func (kr *keyRates) set(pattern string, r float64, burst int) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if burst < 1 {
+		burst = 1
+	}
+	for p := range kr.buckets {
+		if p.pattern == pattern {
+			delete(kr.buckets, p)
+		}
+	}
+	for i, rp := range kr.patterns {
+		if rp.pattern != pattern {
+			continue
+		}
+		if r <= 0 {
+			kr.patterns = append(kr.patterns[:i], kr.patterns[i+1:]...)
+		} else {
+			kr.patterns[i] = ratePattern{pattern: pattern, r: r, burst: burst}
+		}
+		return
+	}
+	if r > 0 {
+		kr.patterns = append(kr.patterns, ratePattern{pattern: pattern, r: r, burst: burst})
+	}
+}
+
+// reserve takes a token from the buckets of keys, and returns the delay until all of them are available.
+func (kr *keyRates) reserve(keys []string, now time.Time) time.Duration {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if len(kr.buckets) >= kr.sweepAt {
+		kr.sweep(now)
+	}
+	var delay time.Duration
+	for _, rp := range kr.patterns {
+		for _, key := range keys {
+			prefix, ok := matchPrefix(rp.pattern, key)
+			if !ok {
+				continue
+			}
+			p := ratePrefix{pattern: rp.pattern, prefix: prefix}
+			b, ok := kr.buckets[p]
+			if !ok {
+				if kr.buckets == nil {
+					kr.buckets = map[ratePrefix]*bucket{}
+				}
+				b = &bucket{r: rp.r, burst: rp.burst, tokens: float64(rp.burst), last: now}
+				kr.buckets[p] = b
+			}
+			if d := b.reserve(now); d > delay {
+				delay = d
+			}
+		}
+	}
+	return delay
+}
+
+// sweep drops the buckets full at now, so that the buckets of prefixes no longer used do not pile up.
+func (kr *keyRates) sweep(now time.Time) {
+	for p, b := range kr.buckets {
+		if b.full(now) {
+			delete(kr.buckets, p)
+		}
+	}
+	kr.sweepAt = 2 * len(kr.buckets)
+	if kr.sweepAt < minSweepAt {
+		kr.sweepAt = minSweepAt
+	}
+}
+
+// wait blocks until a function with keys can be called within the rates, or ctx is done.
+func (kr *keyRates) wait(ctx context.Context, keys []string) error {
+	d := kr.reserve(keys, time.Now())
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bucket is a token bucket filled at r tokens per second up to burst tokens.
+type bucket struct {
+	r      float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// full reports whether b is refilled up to burst tokens at now.
+func (b *bucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.r >= float64(b.burst)
+}
+
+// reserve takes a token, and returns the delay until it is available.
+// The tokens go negative for the reservations ahead of the refill.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.r
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.r * float64(time.Second))
+}
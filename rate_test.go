@@ -0,0 +1,73 @@
+package concgroup_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestKeyRate(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetKeyRate("api.example.com", 20, 2)
+	var (
+		mu    sync.Mutex
+		calls = map[string][]time.Time{}
+	)
+	start := time.Now()
+	for _, key := range []string{"api.example.com", "api.example.com", "api.example.com", "api.example.com", "other.example.com", "other.example.com", "other.example.com"} {
+		key := key
+		cg.Go(key, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[key] = append(calls[key], time.Now())
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	paced := calls["api.example.com"]
+	// The first 2 calls are the burst, and the others are paced at 50ms intervals.
+	if got := paced[1].Sub(start); got >= 50*time.Millisecond {
+		t.Errorf("got the second call after %v, want it in the burst", got)
+	}
+	if got, want := paced[3].Sub(start), 100*time.Millisecond-time.Millisecond; got < want {
+		t.Errorf("got the fourth call after %v, want at least %v", got, want)
+	}
+	other := calls["other.example.com"]
+	if got := other[2].Sub(start); got >= 50*time.Millisecond {
+		t.Errorf("got the calls of other key after %v, want them unpaced", got)
+	}
+}
+
+func TestKeyRatePattern(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetKeyRate("tenant/*", 20, 1)
+	var (
+		mu    sync.Mutex
+		calls []time.Time
+	)
+	start := time.Now()
+	// Different keys under the same tenant share the rate.
+	for _, key := range []string{"tenant/a/job/1", "tenant/a/job/2", "tenant/a/job/3"} {
+		cg.Go(key, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, time.Now())
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := time.Since(start), 100*time.Millisecond-time.Millisecond; got < want {
+		t.Errorf("got the calls in %v, want at least %v", got, want)
+	}
+	if len(calls) != 3 {
+		t.Errorf("got %d calls, want 3", len(calls))
+	}
+}
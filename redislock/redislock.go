@@ -0,0 +1,204 @@
+// Package redislock provides a concgroup.KeyLocker over Redis, so that the functions of groups in different processes
+// with the same keys do not run at the same time. A lock is a Redis key set with SET NX and a lease,
+// renewed while the function runs and deleted when it returns.
+// It talks to Redis through the Client interface, so the core stays free of a Redis client library.
+// With github.com/redis/go-redis/v9:
+//
+//	type client struct{ *redis.Client }
+//
+//	func (c client) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		return c.Client.Eval(ctx, script, keys, args...).Result()
+//	}
+//
+//	cg.SetKeyLocker(redislock.New(client{rdb}))
+package redislock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLease is the default lease of locks.
+	DefaultLease = 10 * time.Second
+	// DefaultRetryInterval is the default interval to retry acquiring locks held by others.
+	DefaultRetryInterval = 50 * time.Millisecond
+)
+
+// Client is a Redis client running Lua scripts with EVAL. The result is the reply of the script, such as int64.
+type Client interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// acquireScript sets all KEYS to the token ARGV[1] with the lease ARGV[2] in milliseconds if none of them exists.
+const acquireScript = `for _, k in ipairs(KEYS) do
+  if redis.call("EXISTS", k) == 1 then return 0 end
+end
+for _, k in ipairs(KEYS) do
+  redis.call("SET", k, ARGV[1], "PX", ARGV[2])
+end
+return 1`
+
+// renewScript extends the lease of KEYS to ARGV[2] in milliseconds if all of them still have the token ARGV[1].
+const renewScript = `for _, k in ipairs(KEYS) do
+  if redis.call("GET", k) ~= ARGV[1] then return 0 end
+end
+for _, k in ipairs(KEYS) do
+  redis.call("PEXPIRE", k, ARGV[2])
+end
+return 1`
+
+// releaseScript deletes KEYS having the token ARGV[1].
+const releaseScript = `for _, k in ipairs(KEYS) do
+  if redis.call("GET", k) == ARGV[1] then redis.call("DEL", k) end
+end
+return 1`
+
+// Locker is a concgroup.KeyLocker over Redis.
+type Locker struct {
+	client        Client
+	prefix        string
+	lease         time.Duration
+	retryInterval time.Duration
+	onLost        func(keys []string, err error)
+}
+
+// New returns a new Locker using client.
+func New(client Client) *Locker {
+	return &Locker{
+		client:        client,
+		prefix:        "concgroup:",
+		lease:         DefaultLease,
+		retryInterval: DefaultRetryInterval,
+	}
+}
+
+// SetPrefix sets the prefix of the Redis keys of locks. The default is "concgroup:".
+// The settings must be made before the locker is used.
+func (l *Locker) SetPrefix(prefix string) {
+	l.prefix = prefix
+}
+
+// SetLease sets the lease of locks. A lock is renewed every third of the lease while the function runs,
+// and expires after the lease if the process holding it dies. The default is DefaultLease.
+func (l *Locker) SetLease(lease time.Duration) {
+	l.lease = lease
+}
+
+// SetRetryInterval sets the interval to retry acquiring locks held by others. The default is DefaultRetryInterval.
+func (l *Locker) SetRetryInterval(d time.Duration) {
+	l.retryInterval = d
+}
+
+// SetOnLost sets the function called when the locks of a running function cannot be renewed,
+// either because they expired and were taken by others or because Redis failed, to stop the work or alert.
+func (l *Locker) SetOnLost(f func(keys []string, err error)) {
+	l.onLost = f
+}
+
+// errLost is the error passed to the function of SetOnLost when the locks are held by others.
+var errLost = errors.New("redislock: lock lost")
+
+// Acquire acquires the locks of all keys at once, retrying until they are free or ctx is done,
+// and renews them until the returned function is called.
+func (l *Locker) Acquire(ctx context.Context, keys []string) (func(), error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	rkeys := make([]string, len(keys))
+	for i, key := range keys {
+		rkeys[i] = l.prefix + key
+	}
+	lease := l.lease.Milliseconds()
+	for {
+		ok, err := l.eval(ctx, acquireScript, rkeys, token, lease)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		timer := time.NewTimer(l.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.renew(stop, keys, rkeys, token, lease)
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			wg.Wait()
+			// Release with a fresh context: the context of the function may be canceled already.
+			ctx, cancel := context.WithTimeout(context.Background(), l.lease)
+			defer cancel()
+			_, _ = l.client.Eval(ctx, releaseScript, rkeys, token)
+		})
+	}, nil
+}
+
+// renew renews the locks every third of the lease until stop is closed.
+func (l *Locker) renew(stop <-chan struct{}, keys, rkeys []string, token string, lease int64) {
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), l.lease/3)
+		ok, err := l.eval(ctx, renewScript, rkeys, token, lease)
+		cancel()
+		if err == nil && !ok {
+			err = errLost
+		}
+		if err != nil {
+			if l.onLost != nil {
+				l.onLost(keys, err)
+			}
+			if errors.Is(err, errLost) {
+				return
+			}
+		}
+	}
+}
+
+// eval runs script and reports whether it returned 1.
+func (l *Locker) eval(ctx context.Context, script string, keys []string, args ...any) (bool, error) {
+	v, err := l.client.Eval(ctx, script, keys, args...)
+	if err != nil {
+		return false, err
+	}
+	switch n := v.(type) {
+	case int64:
+		return n == 1, nil
+	case int:
+		return n == 1, nil
+	default:
+		return false, fmt.Errorf("redislock: unexpected reply %v", v)
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,140 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+// fakeRedis runs the scripts of Locker in memory.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttl  map[string]int64
+	err  error
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: map[string]string{}, ttl: map[string]int64{}}
+}
+
+func (r *fakeRedis) Eval(_ context.Context, script string, keys []string, args ...any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	token := args[0].(string)
+	switch script {
+	case acquireScript:
+		for _, k := range keys {
+			if _, ok := r.data[k]; ok {
+				return int64(0), nil
+			}
+		}
+		for _, k := range keys {
+			r.data[k] = token
+			r.ttl[k] = args[1].(int64)
+		}
+		return int64(1), nil
+	case renewScript:
+		for _, k := range keys {
+			if r.data[k] != token {
+				return int64(0), nil
+			}
+		}
+		for _, k := range keys {
+			r.ttl[k] = args[1].(int64)
+		}
+		return int64(1), nil
+	case releaseScript:
+		for _, k := range keys {
+			if r.data[k] == token {
+				delete(r.data, k)
+				delete(r.ttl, k)
+			}
+		}
+		return int64(1), nil
+	}
+	return nil, errors.New("unknown script")
+}
+
+func TestLocker(t *testing.T) {
+	rdb := newFakeRedis()
+	l := New(rdb)
+	l.SetRetryInterval(time.Millisecond)
+	// Two groups share the locker like two replicas of a service.
+	var running atomic.Int32
+	var overlapped atomic.Bool
+	var groups []*concgroup.Group
+	for i := 0; i < 2; i++ {
+		cg := &concgroup.Group{}
+		cg.SetKeyLocker(l)
+		for j := 0; j < 5; j++ {
+			cg.Go("key", func() error {
+				if running.Add(1) > 1 {
+					overlapped.Store(true)
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+		}
+		groups = append(groups, cg)
+	}
+	for _, cg := range groups {
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if overlapped.Load() {
+		t.Error("functions with the same key ran at the same time across groups")
+	}
+	if len(rdb.data) != 0 {
+		t.Errorf("got %v, want all released", rdb.data)
+	}
+}
+
+func TestLockerRenew(t *testing.T) {
+	rdb := newFakeRedis()
+	l := New(rdb)
+	l.SetLease(30 * time.Millisecond)
+	lost := make(chan []string, 1)
+	l.SetOnLost(func(keys []string, err error) {
+		if errors.Is(err, errLost) {
+			lost <- keys
+		}
+	})
+	release, err := l.Acquire(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	rdb.mu.Lock()
+	rdb.data["concgroup:b"] = "other"
+	rdb.mu.Unlock()
+	select {
+	case keys := <-lost:
+		if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+			t.Errorf("got %v, want [a b]", keys)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the lost lock was not reported")
+	}
+}
+
+func TestLockerCancel(t *testing.T) {
+	rdb := newFakeRedis()
+	rdb.data["concgroup:key"] = "other"
+	l := New(rdb)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, []string{"key"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,109 @@
+package concgroup
+
+import (
+	"sync"
+)
+
+// Schedule is the order functions started in, as the sequence numbers of their submission to a group (Event.Seq).
+type Schedule []uint64
+
+// Recorder is an EventSink recording the Schedule of a run, to reproduce ordering-dependent bugs with Replay.
+type Recorder struct {
+	mu    sync.Mutex
+	order Schedule
+}
+
+// Record implements EventSink.
+func (r *Recorder) Record(e Event) {
+	if e.Kind != EventStarted {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = append(r.order, e.Seq)
+}
+
+// Schedule returns the recorded Schedule.
+func (r *Recorder) Schedule() Schedule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(Schedule(nil), r.order...)
+}
+
+// Replay makes functions start in the order of s, given the same functions are submitted in the same order as the recorded run.
+// A function starts acquiring its key locks only after all the functions before it in s have started or been canceled.
+// Functions not in s start after all the functions in s.
+// While replaying, a function takes its slots of the limit set by SetLimit when its turn comes instead of at the
+// submission, so Go does not block on the limit.
+// It must be called before submitting functions.
+func (g *Group) Replay(s Schedule) {
+	g.replay.set(s)
+}
+
+// replayer gates functions to start in the order of a Schedule.
+type replayer struct {
+	mu    sync.Mutex
+	index map[uint64]int
+	len   int
+	pos   int
+	// changed is closed and replaced when pos advances.
+	changed chan struct{}
+}
+
+func (r *replayer) set(s Schedule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.index = make(map[uint64]int, len(s))
+	for i, seq := range s {
+		r.index[seq] = i
+	}
+	r.len = len(s)
+	r.pos = 0
+	r.changed = make(chan struct{})
+}
+
+// enabled reports whether a Schedule is replayed.
+// Functions are not queued for their keys in the order of submission then, as the Schedule decides the order.
+func (r *replayer) enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.index != nil
+}
+
+// wait blocks until it is the turn of t, or t is canceled.
+func (r *replayer) wait(t *task) {
+	r.mu.Lock()
+	if r.index == nil {
+		r.mu.Unlock()
+		return
+	}
+	turn, ok := r.index[t.seq]
+	if !ok {
+		turn = r.len
+	}
+	for r.pos < turn {
+		changed := r.changed
+		r.mu.Unlock()
+		select {
+		case <-changed:
+		case <-t.ctx.Done():
+			return
+		}
+		r.mu.Lock()
+	}
+	r.mu.Unlock()
+}
+
+// done passes the turn of t to the next function. It is no-op unless it is the turn of t.
+func (r *replayer) done(t *task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index == nil {
+		return
+	}
+	if turn, ok := r.index[t.seq]; ok && turn == r.pos {
+		r.pos++
+		close(r.changed)
+		r.changed = make(chan struct{})
+	}
+}
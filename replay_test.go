@@ -0,0 +1,86 @@
+package concgroup_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+	run := func(schedule concgroup.Schedule) concgroup.Schedule {
+		cg := new(concgroup.Group)
+		if schedule != nil {
+			cg.Replay(schedule)
+		}
+		rec := new(concgroup.Recorder)
+		cg.AddEventSink(rec)
+		for i := 0; i < 20; i++ {
+			cg.Go(fmt.Sprintf("key-%d", i%3), func() error {
+				time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond) //nolint:gosec
+				return nil
+			})
+		}
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Schedule()
+	}
+	want := run(nil)
+	if len(want) != 20 {
+		t.Fatalf("got %d recorded starts, want 20", len(want))
+	}
+	for i := 0; i < 3; i++ {
+		if got := run(want); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestReplayForcedOrder(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.Replay(concgroup.Schedule{5, 4, 3, 2, 1})
+	rec := new(concgroup.Recorder)
+	cg.AddEventSink(rec)
+	for i := 1; i <= 6; i++ {
+		cg.Go(fmt.Sprintf("key-%d", i), func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(rec.Schedule()), "[5 4 3 2 1 6]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReplayLimit(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetLimit(1)
+	cg.Replay(concgroup.Schedule{3, 2, 1})
+	rec := new(concgroup.Recorder)
+	cg.AddEventSink(rec)
+	for i := 1; i <= 3; i++ {
+		cg.Go(fmt.Sprintf("key-%d", i), func() error { return nil })
+	}
+	done := make(chan error)
+	go func() { done <- cg.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("deadlocked waiting for the turn with the slot of the limit")
+	}
+	if got, want := fmt.Sprint(rec.Schedule()), "[3 2 1]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := cg.Stats().MaxConcurrency; got != 1 {
+		t.Errorf("got %d, want 1", got)
+	}
+}
@@ -0,0 +1,82 @@
+package concgroup
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Reset makes the group reusable for another run after Wait has returned, for a long-running worker processing
+// cycles of functions. It clears the state of the previous run: the error, the cancellation of the context,
+// the error budget, the keys quarantined on panic, the IDs of GoAfter, the closing and pacing by Close or Drain,
+// the RunStats except the per-key statistics, and the progress, so that OnComplete is called again for the new run.
+// The configuration such as the limits and the per-key settings, and the per-key state such as paused keys,
+// circuit breakers and per-key statistics, are kept. For a group created by WithContext, the context of the new run is
+// derived from the parent context again and returned; otherwise it returns the background context.
+// A group created by FromErrgroup continues with a new errgroup.Group without the settings of the original one.
+// It must not be called while functions are submitted or running.
+func (g *Group) Reset() context.Context {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.init()
+	if g.parent != nil {
+		g.eg, g.ctx = errgroup.WithContext(g.parent)
+	} else {
+		g.eg, g.ctx = &errgroup.Group{}, context.Background()
+	}
+	g.closed = false
+	g.opened = nil
+	if g.closeOnCancel {
+		g.watchCancel()
+	}
+	g.failed.Store(false)
+	g.budget.reset()
+	g.failures.take()
+	g.keyErrs.take()
+	g.panicMu.Lock()
+	g.recovered = nil
+	g.panicMu.Unlock()
+	g.locks.reset(g)
+	g.dag.reset()
+	g.stats.reset()
+	g.progress.reset()
+	g.pacer.reset()
+	return g.ctx
+}
+
+func (b *errorBudget) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errs = nil
+}
+
+// reset clears the cancellation of all the tasks of the group g and its quarantine.
+func (lt *lockTable) reset(g *Group) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	delete(lt.scopes, g)
+}
+
+// reset starts the RunStats of a new run, keeping the per-key statistics.
+func (s *stats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.run = RunStats{}
+	s.running = 0
+	s.changed = time.Time{}
+	s.completed = false
+}
+
+func (p *progress) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.p = Progress{}
+	p.last = time.Time{}
+}
+
+func (d *dag) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nodes = nil
+}
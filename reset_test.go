@@ -0,0 +1,122 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+	cg, ctx := concgroup.WithContext(context.Background())
+	cg.SetLimit(1)
+	cg.SetCloseOnCancel(true)
+	errTask := errors.New("task error")
+	cg.Go("samegroup", func() error { return errTask })
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Fatalf("got %v, want %v", err, errTask)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("the context of the run is not canceled")
+	}
+	ctx = cg.Reset()
+	if ctx.Err() != nil {
+		t.Fatalf("the context of the new run is canceled: %v", ctx.Err())
+	}
+	var running, maxRunning int64
+	for _, key := range []string{"a", "b", "c"} {
+		cg.Go(key, func() error {
+			n := atomic.AddInt64(&running, 1)
+			defer atomic.AddInt64(&running, -1)
+			if n > atomic.LoadInt64(&maxRunning) {
+				atomic.StoreInt64(&maxRunning, n)
+			}
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning != 1 {
+		t.Errorf("got max %d running functions, want the limit 1 kept", maxRunning)
+	}
+}
+
+func TestResetClosed(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.Close()
+	if cg.TryGo("samegroup", func() error { return nil }) {
+		t.Error("TryGo after Close succeeded")
+	}
+	cg.Reset()
+	called := false
+	cg.Go("samegroup", func() error {
+		called = true
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("function is not called after Reset")
+	}
+}
+
+func TestResetStats(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	var completed []int
+	cg.OnComplete(func(rs concgroup.RunStats) {
+		completed = append(completed, rs.Submitted)
+	})
+	var progress []string
+	cg.OnProgress(func(done, total int) {
+		progress = append(progress, fmt.Sprintf("%d/%d", done, total))
+	})
+	for _, n := range []int{2, 1} {
+		progress = nil
+		for i := 0; i < n; i++ {
+			cg.Go("samegroup", func() error { return nil })
+		}
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if got := cg.Stats(); got.Submitted != n || got.Succeeded != n {
+			t.Errorf("got %d submitted and %d succeeded, want %d", got.Submitted, got.Succeeded, n)
+		}
+		if got, want := progress[len(progress)-1], fmt.Sprintf("%d/%d", n, n); got != want {
+			t.Errorf("got progress %s, want %s", got, want)
+		}
+		cg.Reset()
+	}
+	if got, want := fmt.Sprint(completed), "[2 1]"; got != want {
+		t.Errorf("got OnComplete calls with %s submitted, want %s", got, want)
+	}
+}
+
+func TestResetDrained(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetDrainRate(2)
+	if err := cg.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cg.Reset()
+	start := time.Now()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		cg.Go(key, func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("got %v, want the functions after Reset not paced by the drain rate", elapsed)
+	}
+}
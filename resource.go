@@ -0,0 +1,165 @@
+package concgroup
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefineLimiter defines the named limiter that has n units of a resource, limiting the number of functions consuming it
+// running at the same time. It is shared by all keys, and bounds an external resource such as a database or an API.
+func (g *Group) DefineLimiter(name string, n int) {
+	g.resources.define(name, n)
+}
+
+// GoLimited calls the given function in a new goroutine like Go, consuming one unit of each named limiter defined by DefineLimiter.
+// The function is called when the key lock and all the limiters are acquired.
+func (g *Group) GoLimited(key string, limiters []string, f func() error) {
+	g.submit(spec{keys: []string{key}, resources: units(limiters)}, Adapt(f))
+}
+
+// GoMultiLimited calls the given function in a new goroutine like GoMulti, consuming one unit of each named limiter defined by DefineLimiter.
+func (g *Group) GoMultiLimited(keys []string, limiters []string, f func() error) {
+	g.submit(spec{keys: sortedKeys(keys), resources: units(limiters)}, Adapt(f))
+}
+
+// GoResources calls the given function in a new goroutine like Go, consuming the units of the named limiters in resources
+// (e.g. 1 unit of "db" and 2 units of "cpu"). The function is called only when all the units are available at once,
+// so that functions declaring overlapping resources never deadlock.
+func (g *Group) GoResources(key string, resources map[string]int, f func() error) {
+	g.submit(spec{keys: []string{key}, resources: resources}, Adapt(f))
+}
+
+// GoMultiResources calls the given function in a new goroutine like GoMulti, consuming the units of the named limiters in resources.
+func (g *Group) GoMultiResources(keys []string, resources map[string]int, f func() error) {
+	g.submit(spec{keys: sortedKeys(keys), resources: resources}, Adapt(f))
+}
+
+// units returns the resources consuming one unit of each limiter.
+func units(limiters []string) map[string]int {
+	resources := map[string]int{}
+	for _, name := range limiters {
+		resources[name]++
+	}
+	return resources
+}
+
+// resources manages the named limiters of a Group.
+type resources struct {
+	mu     sync.Mutex
+	limits map[string]int
+	used   map[string]int
+	// refs is the number of functions acquiring or holding each limiter of SetLimitPattern.
+	refs map[string]int
+	// changed is closed and replaced when a limiter is released or defined.
+	changed chan struct{}
+}
+
+func (r *resources) define(name string, n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+	r.limits[name] = n
+	r.broadcast()
+}
+
+// ref defines the limiters of patterns with their units unless they are already defined so,
+// and counts a reference to them. r.mu must be held.
+func (r *resources) ref(patterns map[string]int) {
+	for name, n := range patterns {
+		r.refs[name]++
+		if m, ok := r.limits[name]; ok && m == n {
+			continue
+		}
+		r.limits[name] = n
+		r.broadcast()
+	}
+}
+
+// unref drops a reference to the limiters of patterns, deleting the ones no longer referenced. r.mu must be held.
+func (r *resources) unref(patterns map[string]int) {
+	for name := range patterns {
+		if r.refs[name]--; r.refs[name] > 0 {
+			continue
+		}
+		delete(r.refs, name)
+		delete(r.limits, name)
+		delete(r.used, name)
+	}
+}
+
+// acquire blocks until all the units of the limiters are available and acquires them at once, so that it never deadlocks.
+// The limiters of patterns are defined while they are acquired or held.
+// It returns ErrCanceled if done is closed before that.
+func (r *resources) acquire(units, patterns map[string]int, done <-chan struct{}) error {
+	if len(units) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	r.init()
+	r.ref(patterns)
+	for {
+		ok := true
+		for name, u := range units {
+			n, defined := r.limits[name]
+			if !defined {
+				r.unref(patterns)
+				r.mu.Unlock()
+				return fmt.Errorf("concgroup: undefined limiter %q", name)
+			}
+			if u > n {
+				r.unref(patterns)
+				r.mu.Unlock()
+				return fmt.Errorf("concgroup: %d units of limiter %q exceed its %d units", u, name, n)
+			}
+			if r.used[name]+u > n {
+				ok = false
+			}
+		}
+		if ok {
+			break
+		}
+		changed := r.changed
+		r.mu.Unlock()
+		select {
+		case <-changed:
+		case <-done:
+			r.mu.Lock()
+			r.unref(patterns)
+			r.mu.Unlock()
+			return ErrCanceled
+		}
+		r.mu.Lock()
+	}
+	for name, u := range units {
+		r.used[name] += u
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *resources) release(units, patterns map[string]int) {
+	if len(units) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, u := range units {
+		r.used[name] -= u
+	}
+	r.unref(patterns)
+	r.broadcast()
+}
+
+func (r *resources) init() {
+	if r.limits == nil {
+		r.limits = map[string]int{}
+		r.used = map[string]int{}
+		r.refs = map[string]int{}
+		r.changed = make(chan struct{})
+	}
+}
+
+func (r *resources) broadcast() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
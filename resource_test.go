@@ -0,0 +1,114 @@
+package concgroup_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestDefineLimiter(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.DefineLimiter("db", 2)
+	cg.DefineLimiter("api", 1)
+	mu := sync.Mutex{}
+	running := map[string]int{}
+	enter := func(limiters []string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, l := range limiters {
+			running[l]++
+		}
+		if running["db"] > 2 || running["api"] > 1 {
+			return errors.New("violate limiter")
+		}
+		return nil
+	}
+	leave := func(limiters []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, l := range limiters {
+			running[l]--
+		}
+	}
+	for i := 0; i < 20; i++ {
+		limiters := []string{"db"}
+		if i%3 == 0 {
+			limiters = append(limiters, "api")
+		}
+		cg.GoLimited(fmt.Sprintf("group-%d", i), limiters, func() error {
+			if err := enter(limiters); err != nil {
+				return err
+			}
+			defer leave(limiters)
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUndefinedLimiter(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.GoLimited("one", []string{"undefined"}, func() error {
+		return nil
+	})
+	if err := cg.Wait(); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestGoResources(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.DefineLimiter("db", 2)
+	cg.DefineLimiter("cpu", 4)
+	mu := sync.Mutex{}
+	used := map[string]int{}
+	for i := 0; i < 20; i++ {
+		resources := map[string]int{"db": 1, "cpu": 2}
+		if i%2 == 0 {
+			resources = map[string]int{"cpu": 3}
+		}
+		cg.GoResources(fmt.Sprintf("group-%d", i), resources, func() error {
+			mu.Lock()
+			for name, u := range resources {
+				used[name] += u
+			}
+			if used["db"] > 2 || used["cpu"] > 4 {
+				mu.Unlock()
+				return errors.New("violate limiter")
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			for name, u := range resources {
+				used[name] -= u
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGoResourcesExceedLimiter(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.DefineLimiter("cpu", 4)
+	cg.GoResources("one", map[string]int{"cpu": 5}, func() error {
+		return nil
+	})
+	if err := cg.Wait(); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,212 @@
+package concgroup
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TaskResult is the result of a function called by the Group.
+type TaskResult struct {
+	// Keys are the keys of the function.
+	Keys []string
+	// Err is the error returned by the function, or the cause of the cancellation (such as ErrCanceled) if it is canceled before being called.
+	Err error
+	// Canceled reports whether the function is canceled, by CancelKeys or the cancellation of the context of the group for example.
+	Canceled bool
+	// Duration is the duration of the function call.
+	Duration time.Duration
+	// Discarded reports whether the function is discarded by DiscardPending without being called.
+	// Discarded results are not passed to Subscribe and OnResult, but EventSinks still receive them.
+	Discarded bool
+}
+
+// KeyError is an error returned by a function with keys.
+type KeyError struct {
+	// Keys are the keys of the function.
+	Keys []string
+	// Err is the error returned by the function.
+	Err error
+}
+
+// Error implements error.
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("[%s] %v", strings.Join(e.Keys, ", "), e.Err)
+}
+
+// Unwrap returns the error returned by the function.
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// subscriptions delivers TaskResults to subscribers of keys, errors to subscribers of errors,
+// and events to subscribers of the progress.
+type subscriptions struct {
+	mu       sync.Mutex
+	subs     map[string][]*subscription[TaskResult]
+	errs     []*subscription[error]
+	progress []*subscription[ProgressEvent]
+	// onProgress is the functions registered by OnProgress.
+	onProgress []func(done, total int)
+	// calls is the counts to call onProgress with in order, and delivering reports whether a goroutine is calling them.
+	calls      [][2]int
+	delivering bool
+}
+
+func (s *subscriptions) add(key string) <-chan TaskResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs == nil {
+		s.subs = map[string][]*subscription[TaskResult]{}
+	}
+	sub := newSubscription[TaskResult]()
+	s.subs[key] = append(s.subs[key], sub)
+	return sub.ch
+}
+
+func (s *subscriptions) addErrors() <-chan error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := newSubscription[error]()
+	s.errs = append(s.errs, sub)
+	return sub.ch
+}
+
+func (s *subscriptions) addProgress() <-chan ProgressEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := newSubscription[ProgressEvent]()
+	s.progress = append(s.progress, sub)
+	return sub.ch
+}
+
+func (s *subscriptions) addOnProgress(fn func(done, total int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onProgress = append(s.onProgress, fn)
+}
+
+// publishProgress sends e to the subscribers of the progress, and queues the call of the functions registered by
+// OnProgress if e is of a finished event. It is called in the order of the counts of the progress.
+// It reports whether the caller is to call the functions by deliver.
+func (s *subscriptions) publishProgress(e ProgressEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.progress {
+		sub.send(e)
+	}
+	if e.Kind != EventFinished || len(s.onProgress) == 0 {
+		return false
+	}
+	s.calls = append(s.calls, [2]int{e.Done, e.Total})
+	if s.delivering {
+		// The goroutine delivering the calls, which may be this one calling the group from fn, calls fns in order.
+		return false
+	}
+	s.delivering = true
+	return true
+}
+
+// deliver calls the functions registered by OnProgress with the queued counts in order until none is left.
+func (s *subscriptions) deliver() {
+	s.mu.Lock()
+	for len(s.calls) > 0 {
+		c, fns := s.calls[0], s.onProgress
+		s.calls = s.calls[1:]
+		// Call fns without mu so that they can use the group.
+		s.mu.Unlock()
+		for _, fn := range fns {
+			fn(c[0], c[1])
+		}
+		s.mu.Lock()
+	}
+	s.delivering = false
+	s.mu.Unlock()
+}
+
+func (s *subscriptions) publish(r TaskResult) {
+	if r.Discarded {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range r.Keys {
+		for _, sub := range s.subs[key] {
+			sub.send(r)
+		}
+	}
+	if r.Err != nil && !r.Canceled {
+		for _, sub := range s.errs {
+			sub.send(&KeyError{Keys: r.Keys, Err: r.Err})
+		}
+	}
+}
+
+func (s *subscriptions) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, subs := range s.subs {
+		for _, sub := range subs {
+			sub.close()
+		}
+	}
+	for _, sub := range s.errs {
+		sub.close()
+	}
+	for _, sub := range s.progress {
+		sub.close()
+	}
+	s.subs = nil
+	s.errs = nil
+	s.progress = nil
+}
+
+// subscription is an unbounded queue of values forwarded to ch,
+// so that a slow subscriber never blocks the goroutines of the Group.
+type subscription[T any] struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []T
+	closed bool
+	ch     chan T
+}
+
+func newSubscription[T any]() *subscription[T] {
+	s := &subscription[T]{ch: make(chan T)}
+	s.cond = sync.NewCond(&s.mu)
+	go s.forward()
+	return s
+}
+
+func (s *subscription[T]) send(r T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, r)
+	s.cond.Signal()
+}
+
+func (s *subscription[T]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Signal()
+}
+
+func (s *subscription[T]) forward() {
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			close(s.ch)
+			return
+		}
+		r := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		s.ch <- r
+	}
+}
@@ -0,0 +1,136 @@
+package concgroup_test
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	ch := cg.Subscribe("tenant-a")
+	errTask := errors.New("task error")
+	cg.Go("tenant-a", func() error {
+		return nil
+	})
+	cg.Go("tenant-b", func() error {
+		return nil
+	})
+	cg.GoMulti([]string{"tenant-a", "tenant-c"}, func() error {
+		return errTask
+	})
+	go func() {
+		_ = cg.Wait()
+	}()
+	var results []concgroup.TaskResult
+	for r := range ch {
+		results = append(results, r)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	var gotErr bool
+	for _, r := range results {
+		if errors.Is(r.Err, errTask) {
+			gotErr = true
+		}
+	}
+	if !gotErr {
+		t.Error("want the result with error")
+	}
+}
+
+func TestErrch(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	errch := cg.Errch()
+	errTask := errors.New("task error")
+	cg.Go("tenant-a", func() error {
+		return nil
+	})
+	cg.GoMulti([]string{"tenant-b", "tenant-c"}, func() error {
+		return errTask
+	})
+	go func() {
+		_ = cg.Wait()
+	}()
+	var errs []error
+	for err := range errch {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want 1 error", errs)
+	}
+	if !errors.Is(errs[0], errTask) {
+		t.Errorf("got %v, want %v", errs[0], errTask)
+	}
+	var kerr *concgroup.KeyError
+	if !errors.As(errs[0], &kerr) {
+		t.Fatalf("got %T, want *concgroup.KeyError", errs[0])
+	}
+	if len(kerr.Keys) != 2 || kerr.Keys[0] != "tenant-b" {
+		t.Errorf("got %v", kerr.Keys)
+	}
+}
+
+func TestOnResult(t *testing.T) {
+	cg := new(concgroup.Group)
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	cg.OnResult(func(r concgroup.TaskResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Keys...)
+	})
+	for _, key := range []string{"a", "b", "c"} {
+		cg.Go(key, func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "a,b,c" {
+		t.Errorf("got %v, want [a b c]", keys)
+	}
+}
+
+func TestOnStartAndOnError(t *testing.T) {
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	var (
+		mu      sync.Mutex
+		started []string
+		failed  []string
+	)
+	cg.OnStart(func(keys []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		started = append(started, keys...)
+	})
+	cg.OnError(func(r concgroup.TaskResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, r.Keys...)
+	})
+	cg.Go("a", func() error { return nil })
+	cg.Go("b", func() error { return errors.New("failed") })
+	cg.Go("c", func() error { panic("panicked") })
+	if err := cg.Wait(); err == nil {
+		t.Fatal("want error")
+	}
+	sort.Strings(started)
+	if strings.Join(started, ",") != "a,b,c" {
+		t.Errorf("got started %v, want [a b c]", started)
+	}
+	sort.Strings(failed)
+	if strings.Join(failed, ",") != "b,c" {
+		t.Errorf("got failed %v, want [b c]", failed)
+	}
+}
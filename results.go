@@ -0,0 +1,92 @@
+package concgroup
+
+import (
+	"context"
+	"sync"
+)
+
+// Result is the result of a function submitted through Results.
+type Result[T any] struct {
+	// Keys are the keys of the function.
+	Keys []string
+	// Value is the value returned by the function.
+	Value T
+	// Err is the error returned by the function, the *RecoveredPanic if it panicked, or ErrCanceled if the function
+	// was not called.
+	Err error
+}
+
+// Results collects the values returned by functions called by a Group, in the order of submission.
+//
+//	rs := concgroup.NewResults[*http.Response](cg)
+//	rs.Go("go.dev", func() (*http.Response, error) { return http.Get("https://go.dev/") })
+//	results, err := rs.Wait()
+type Results[T any] struct {
+	g       *Group
+	mu      sync.Mutex
+	results []Result[T]
+}
+
+// NewResults returns a new Results calling functions in g.
+func NewResults[T any](g *Group) *Results[T] {
+	return &Results[T]{g: g}
+}
+
+// Go calls the given function in a new goroutine like Group.Go, collecting the value it returns.
+func (rs *Results[T]) Go(key string, f func() (T, error)) {
+	rs.GoCtx(key, func(context.Context) (T, error) { return f() })
+}
+
+// GoCtx calls the given function in a new goroutine like Group.GoCtx, collecting the value it returns.
+func (rs *Results[T]) GoCtx(key string, f func(ctx context.Context) (T, error)) {
+	i := rs.add([]string{key})
+	rs.g.submit(spec{keys: []string{key}, done: rs.done(i)}, rs.wrap(i, f))
+}
+
+// GoMulti calls the given function in a new goroutine like Group.GoMulti, collecting the value it returns.
+func (rs *Results[T]) GoMulti(keys []string, f func() (T, error)) {
+	i := rs.add(sortedKeys(keys))
+	rs.g.submit(spec{keys: sortedKeys(keys), done: rs.done(i)}, rs.wrap(i, func(context.Context) (T, error) { return f() }))
+}
+
+// Wait blocks until all function calls have returned like Group.Wait, and returns the results of the functions
+// submitted through rs in the order of submission. The results are cleared for the next run.
+func (rs *Results[T]) Wait() ([]Result[T], error) {
+	err := rs.g.Wait()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	results := rs.results
+	rs.results = nil
+	return results, err
+}
+
+// add adds the result of a new function, and returns its index.
+func (rs *Results[T]) add(keys []string) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.results = append(rs.results, Result[T]{Keys: keys, Err: ErrCanceled})
+	return len(rs.results) - 1
+}
+
+func (rs *Results[T]) wrap(i int, f func(ctx context.Context) (T, error)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		v, err := f(ctx)
+		rs.mu.Lock()
+		rs.results[i].Value = v
+		rs.results[i].Err = err
+		rs.mu.Unlock()
+		return err
+	}
+}
+
+// done returns the callback of the i-th function storing the *RecoveredPanic as its error if it panicked,
+// since wrap does not return then.
+func (rs *Results[T]) done(i int) func(TaskResult) {
+	return func(r TaskResult) {
+		if p, ok := r.Err.(*RecoveredPanic); ok {
+			rs.mu.Lock()
+			rs.results[i].Err = p
+			rs.mu.Unlock()
+		}
+	}
+}
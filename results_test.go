@@ -0,0 +1,74 @@
+package concgroup_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestResults(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	rs := concgroup.NewResults[int](cg)
+	errTask := errors.New("task error")
+	for i := 0; i < 10; i++ {
+		i := i
+		rs.Go(fmt.Sprintf("key-%d", i%3), func() (int, error) {
+			if i == 5 {
+				return 0, errTask
+			}
+			return i * i, nil
+		})
+	}
+	rs.GoMulti([]string{"key-1", "key-0"}, func() (int, error) {
+		return 100, nil
+	})
+	results, err := rs.Wait()
+	if !errors.Is(err, errTask) {
+		t.Errorf("got %v, want %v", err, errTask)
+	}
+	if len(results) != 11 {
+		t.Fatalf("got %d results, want 11", len(results))
+	}
+	for i, r := range results[:10] {
+		if i == 5 {
+			if !errors.Is(r.Err, errTask) {
+				t.Errorf("got %v, want %v", r.Err, errTask)
+			}
+			continue
+		}
+		if r.Err != nil || r.Value != i*i || r.Keys[0] != fmt.Sprintf("key-%d", i%3) {
+			t.Errorf("got %+v, want %d of key-%d", r, i*i, i%3)
+		}
+	}
+	if r := results[10]; r.Value != 100 || fmt.Sprint(r.Keys) != "[key-0 key-1]" {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestResultsPanic(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	rs := concgroup.NewResults[int](cg)
+	rs.Go("key", func() (int, error) {
+		panic("boom")
+	})
+	rs.Go("key", func() (int, error) {
+		return 1, nil
+	})
+	results, err := rs.Wait()
+	var p *concgroup.RecoveredPanic
+	if !errors.As(err, &p) {
+		t.Errorf("got %v, want a *RecoveredPanic", err)
+	}
+	if !errors.As(results[0].Err, &p) || p.Value != "boom" {
+		t.Errorf("got %v, want the recovered panic", results[0].Err)
+	}
+	if results[1].Err != nil || results[1].Value != 1 {
+		t.Errorf("got %v, %v, want 1, nil", results[1].Value, results[1].Err)
+	}
+}
@@ -0,0 +1,55 @@
+package concgroup
+
+import (
+	"context"
+	"sync"
+)
+
+// LockKey blocks until it holds the key lock of key in the group like a function with key, and returns the function
+// to unlock it, so that code outside the group such as a synchronous admin operation excludes the functions with key.
+// It takes no slot of the limit and is not a function of the group: it emits no events and Wait does not wait for it.
+// With SetKeyLocker, it acquires the locks of the locker as well.
+// It returns the error of ctx if ctx is done before the lock is taken, or ErrCanceled if the waiting is canceled such as
+// by CancelKeys or the failure of the group.
+func (g *Group) LockKey(ctx context.Context, key string) (unlock func(), err error) {
+	return g.lockKeys(ctx, []string{key})
+}
+
+// LockMultiKey blocks until it holds the key locks of keys like LockKey.
+func (g *Group) LockMultiKey(ctx context.Context, keys []string) (unlock func(), err error) {
+	return g.lockKeys(ctx, sortedKeys(keys))
+}
+
+func (g *Group) lockKeys(ctx context.Context, keys []string) (func(), error) {
+	g.init()
+	keys, err := g.locks.admit(keys, true)
+	if err != nil {
+		return nil, err
+	}
+	t := g.locks.newTask(ctx, spec{keys: keys, owner: g, caller: callerOf(ctx), site: g.locks.callSite()}, true)
+	g.locks.audit(t)
+	if err := g.locks.acquire(t); err != nil {
+		return nil, err
+	}
+	release := func() {
+		g.locks.release(t)
+	}
+	g.mu.RLock()
+	locker := g.locker
+	g.mu.RUnlock()
+	if locker != nil && len(keys) > 0 {
+		unlock, err := locker.Acquire(t.ctx, keys)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		release = func() {
+			unlock()
+			g.locks.release(t)
+		}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(release)
+	}, nil
+}
@@ -0,0 +1,65 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestLockKey(t *testing.T) {
+	cg := &concgroup.Group{}
+	unlock, err := cg.LockKey(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var called atomic.Bool
+	cg.Go("key", func() error {
+		called.Store(true)
+		return nil
+	})
+	cg.Go("other", func() error {
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+	if called.Load() {
+		t.Error("the function was called while the key was locked")
+	}
+	unlock()
+	unlock()
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !called.Load() {
+		t.Error("the function was not called after unlocking")
+	}
+}
+
+func TestLockMultiKeyContext(t *testing.T) {
+	cg := &concgroup.Group{}
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("b", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cg.LockMultiKey(ctx, []string{"a", "b"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := cg.LockMultiKey(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
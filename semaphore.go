@@ -0,0 +1,32 @@
+package concgroup
+
+import (
+	"golang.org/x/sync/semaphore"
+)
+
+// SetExternalSemaphore makes every function acquire weight(key) of sem summed over its keys before it is called,
+// and release it after it returns, to share a budget such as database connections with code outside the group.
+// The semaphore is acquired after the key locks, so that a function waiting for it does not hold sem while waiting for a key.
+func (g *Group) SetExternalSemaphore(sem *semaphore.Weighted, weight func(key string) int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sem = externalSemaphore{sem: sem, weight: weight}
+}
+
+// externalSemaphore is a semaphore shared with code outside the group.
+type externalSemaphore struct {
+	sem    *semaphore.Weighted
+	weight func(key string) int64
+}
+
+// weightOf returns the weight of a function with keys.
+func (s externalSemaphore) weightOf(keys []string) int64 {
+	if s.sem == nil {
+		return 0
+	}
+	var n int64
+	for _, key := range keys {
+		n += s.weight(key)
+	}
+	return n
+}
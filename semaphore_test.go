@@ -0,0 +1,73 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+func TestExternalSemaphore(t *testing.T) {
+	t.Parallel()
+	sem := semaphore.NewWeighted(3)
+	cg := new(concgroup.Group)
+	cg.SetExternalSemaphore(sem, func(key string) int64 {
+		if key == "heavy" {
+			return 3
+		}
+		return 1
+	})
+	var used int64
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		w := int64(1)
+		if i%5 == 0 {
+			key, w = "heavy", 3
+		}
+		cg.Go(key, func() error {
+			if n := atomic.AddInt64(&used, w); n > 3 {
+				return fmt.Errorf("got %d units used, want at most 3", n)
+			}
+			defer atomic.AddInt64(&used, -w)
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if !sem.TryAcquire(3) {
+		t.Error("semaphore is not released")
+	}
+}
+
+func TestExternalSemaphoreCanceled(t *testing.T) {
+	t.Parallel()
+	sem := semaphore.NewWeighted(1)
+	if err := sem.Acquire(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	cg := new(concgroup.Group)
+	cg.SetExternalSemaphore(sem, func(string) int64 { return 1 })
+	results := cg.Subscribe("a")
+	called := false
+	cg.GoTimeout("a", 10*time.Millisecond, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if called {
+		t.Error("function is called without the semaphore")
+	}
+	r := <-results
+	if !r.Canceled || !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Errorf("got %+v, want canceled by deadline", r)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build go1.23
+
+package concgroup
+
+import (
+	"iter"
+)
+
+// GoSeq calls task for each item pulled from seq in a new goroutine with the key lock of key(item) like GoEach.
+// Items are pulled one at a time as functions are submitted, so with SetLimit the iterator is advanced only as
+// goroutines become available instead of being materialized up front. It stops pulling from seq when the context
+// of the group is canceled, and returns after the last item is submitted.
+func GoSeq[T any](g *Group, seq iter.Seq[T], key func(item T) string, task func(item T) error) {
+	for item := range seq {
+		if g.canceled() {
+			return
+		}
+		g.Go(key(item), func() error {
+			return task(item)
+		})
+	}
+}
+
+// canceled reports whether the context of the current run of the group is canceled.
+func (g *Group) canceled() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	g.init()
+	return g.ctx.Err() != nil
+}
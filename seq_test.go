@@ -0,0 +1,71 @@
+//go:build go1.23
+
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestGoSeq(t *testing.T) {
+	cg := &concgroup.Group{}
+	cg.SetLimit(2)
+	var pulled, finished atomic.Int32
+	var ahead atomic.Bool
+	seq := func(yield func(int) bool) {
+		for i := 0; i < 20; i++ {
+			// A goroutine for the item is submitted only after the one of an earlier item returns.
+			if pulled.Load()-finished.Load() > 2 {
+				ahead.Store(true)
+			}
+			pulled.Add(1)
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	var sum atomic.Int32
+	concgroup.GoSeq(cg, seq, func(i int) string {
+		if i%2 == 0 {
+			return "even"
+		}
+		return "odd"
+	}, func(i int) error {
+		sum.Add(int32(i))
+		finished.Add(1)
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sum.Load(); got != 190 {
+		t.Errorf("got %d, want %d", got, 190)
+	}
+	if ahead.Load() {
+		t.Error("the iterator was advanced ahead of the limit")
+	}
+}
+
+func TestGoSeqCanceled(t *testing.T) {
+	cg, _ := concgroup.WithContext(context.Background())
+	failed := errors.New("failed")
+	seq := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	concgroup.GoSeq(cg, seq, func(int) string {
+		return "key"
+	}, func(int) error {
+		return failed
+	})
+	if err := cg.Wait(); !errors.Is(err, failed) {
+		t.Errorf("got %v, want %v", err, failed)
+	}
+}
@@ -0,0 +1,68 @@
+//go:build go1.21
+
+package concgroup
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SetLogger makes the group log the lifecycle of functions to l: submission and the start of waiting for the key
+// locks, the end of waiting when the function is called, and its completion, at the debug level, and errors of
+// functions at the error level. Each record has the attribute "key" with the keys of the function joined by ","
+// and "task" with its sequence number, so that contention on a key can be traced without instrumenting functions.
+func (g *Group) SetLogger(l *slog.Logger) {
+	g.AddEventSink(&logSink{l: l, submitted: map[uint64]time.Time{}})
+}
+
+// logSink logs events to a slog.Logger.
+type logSink struct {
+	l         *slog.Logger
+	mu        sync.Mutex
+	submitted map[uint64]time.Time
+}
+
+func (s *logSink) Record(e Event) {
+	ctx := context.Background()
+	attrs := []slog.Attr{
+		slog.String("key", strings.Join(e.Keys, ",")),
+		slog.Uint64("task", e.Seq),
+	}
+	switch e.Kind {
+	case EventSubmitted:
+		if e.Seq != 0 {
+			s.mu.Lock()
+			s.submitted[e.Seq] = e.Time
+			s.mu.Unlock()
+		}
+		s.l.LogAttrs(ctx, slog.LevelDebug, "concgroup: submitted, waiting for key locks", attrs...)
+	case EventStarted:
+		s.mu.Lock()
+		at, ok := s.submitted[e.Seq]
+		delete(s.submitted, e.Seq)
+		s.mu.Unlock()
+		if ok {
+			attrs = append(attrs, slog.Duration("wait", e.Time.Sub(at)))
+		}
+		s.l.LogAttrs(ctx, slog.LevelDebug, "concgroup: acquired key locks, started", attrs...)
+	case EventFinished:
+		s.mu.Lock()
+		delete(s.submitted, e.Seq)
+		s.mu.Unlock()
+		r := e.Result
+		attrs = append(attrs, slog.Duration("duration", r.Duration))
+		switch {
+		case r.Canceled:
+			attrs = append(attrs, slog.Any("cause", r.Err))
+			s.l.LogAttrs(ctx, slog.LevelDebug, "concgroup: canceled", attrs...)
+		case r.Err != nil:
+			attrs = append(attrs, slog.Any("error", r.Err))
+			s.l.LogAttrs(ctx, slog.LevelError, "concgroup: failed", attrs...)
+		default:
+			s.l.LogAttrs(ctx, slog.LevelDebug, "concgroup: finished", attrs...)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build go1.21
+
+package concgroup_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSetLogger(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	l := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cg := new(concgroup.Group)
+	cg.SetLogger(l)
+	cg.Go("tenant-a", func() error { return errors.New("unavailable") })
+	if err := cg.Wait(); err == nil {
+		t.Fatal("want error")
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="concgroup: submitted, waiting for key locks" key=tenant-a task=1`,
+		`level=DEBUG msg="concgroup: acquired key locks, started" key=tenant-a task=1 wait=`,
+		`level=ERROR msg="concgroup: failed" key=tenant-a task=1 duration=`,
+		`error=unavailable`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want %q in it", got, want)
+		}
+	}
+}
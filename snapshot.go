@@ -0,0 +1,129 @@
+package concgroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrSnapshotted is the error of a pending function taken out of the group by SnapshotPending.
+var ErrSnapshotted = errors.New("concgroup: snapshotted")
+
+// ErrNotSerializable is the error of SnapshotPending for pending functions not submitted by GoTask.
+var ErrNotSerializable = errors.New("concgroup: not serializable")
+
+// TaskDescriptor is a serializable description of a function, calling the handler registered by Handle with the payload.
+type TaskDescriptor struct {
+	// Keys are the keys of the function.
+	Keys []string `json:"keys"`
+	// Handler is the name of the handler.
+	Handler string `json:"handler"`
+	// Payload is the argument of the handler.
+	Payload []byte `json:"payload,omitempty"`
+	// Priority is the priority of the function like GoPriority.
+	Priority int `json:"priority,omitempty"`
+}
+
+// Handle registers h as the handler named name for functions submitted by GoTask.
+func (g *Group) Handle(name string, h func(ctx context.Context, payload []byte) error) {
+	g.handlers.add(name, h)
+}
+
+// GoTask calls the handler of d with its payload in a new goroutine like GoMultiPriority.
+// Unlike the other Go methods, a function submitted by GoTask can be taken out of the group by SnapshotPending
+// and restored in another group by RestorePending.
+// It returns an error if the handler of d is not registered.
+func (g *Group) GoTask(d TaskDescriptor) error {
+	h, err := g.handlers.get(d.Handler)
+	if err != nil {
+		return err
+	}
+	g.submit(spec{keys: sortedKeys(d.Keys), priority: d.Priority, desc: &d}, func(ctx context.Context) error {
+		return h(ctx, d.Payload)
+	})
+	return nil
+}
+
+// SnapshotPending takes the functions submitted by GoTask that have not started yet out of the group, and returns their descriptors
+// in the order of submission, so that a graceful shutdown can persist them and another process can RestorePending them.
+// The functions taken out are not called, and their results have ErrSnapshotted.
+// Pending functions submitted by the other Go methods are left in the group, and ErrNotSerializable is returned with them.
+func (g *Group) SnapshotPending() ([]TaskDescriptor, error) {
+	g.init()
+	tasks, left := g.locks.takePending(g, func(t *task) bool { return t.desc != nil }, ErrSnapshotted)
+	ds := make([]TaskDescriptor, 0, len(tasks))
+	for _, t := range tasks {
+		ds = append(ds, *t.desc)
+	}
+	if left > 0 {
+		return ds, fmt.Errorf("%w: %d pending functions", ErrNotSerializable, left)
+	}
+	return ds, nil
+}
+
+// RestorePending submits the functions of ds by GoTask in order.
+// It returns an error without submitting any of them if the handler of any of them is not registered.
+func (g *Group) RestorePending(ds []TaskDescriptor) error {
+	for _, d := range ds {
+		if _, err := g.handlers.get(d.Handler); err != nil {
+			return err
+		}
+	}
+	for _, d := range ds {
+		if err := g.GoTask(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handlers is the registry of the handlers of TaskDescriptors.
+type handlers struct {
+	mu       sync.Mutex
+	handlers map[string]func(ctx context.Context, payload []byte) error
+}
+
+func (hs *handlers) add(name string, h func(ctx context.Context, payload []byte) error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.handlers == nil {
+		hs.handlers = map[string]func(ctx context.Context, payload []byte) error{}
+	}
+	hs.handlers[name] = h
+}
+
+func (hs *handlers) get(name string) (func(ctx context.Context, payload []byte) error, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	h, ok := hs.handlers[name]
+	if !ok {
+		return nil, fmt.Errorf("concgroup: unknown handler %q", name)
+	}
+	return h, nil
+}
+
+// takePending cancels the tasks of the group g not running yet for which match returns true with cause, and returns them in the order of submission.
+// It also returns the number of the tasks not running yet for which match returns false.
+func (lt *lockTable) takePending(g *Group, match func(t *task) bool, cause error) ([]*task, int) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	var (
+		tasks []*task
+		left  int
+	)
+	lt.eachTask(func(t *task) {
+		if t.owner != g || t.running || t.canceled {
+			return
+		}
+		if !match(t) {
+			left++
+			return
+		}
+		lt.cancelTask(t, cause)
+		tasks = append(tasks, t)
+	})
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].seq < tasks[j].seq })
+	return tasks, left
+}
@@ -0,0 +1,86 @@
+package concgroup_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSnapshotAndRestorePending(t *testing.T) {
+	t.Parallel()
+	var (
+		mu     sync.Mutex
+		called []string
+	)
+	handler := func(ctx context.Context, payload []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		called = append(called, string(payload))
+		return nil
+	}
+	cg := new(concgroup.Group)
+	cg.Handle("print", handler)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cg.Go("a", func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	for i := 0; i < 3; i++ {
+		if err := cg.GoTask(concgroup.TaskDescriptor{Keys: []string{"a"}, Handler: "print", Payload: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cg.Go("a", func() error { return nil })
+	ds, err := cg.SnapshotPending()
+	if !errors.Is(err, concgroup.ErrNotSerializable) {
+		t.Errorf("got %v, want %v", err, concgroup.ErrNotSerializable)
+	}
+	close(release)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 3 {
+		t.Errorf("got %d descriptors, want 3", len(ds))
+	}
+	for i, d := range ds {
+		if got := string(d.Payload); got != fmt.Sprint(i) {
+			t.Errorf("got %s, want %d in the order of submission", got, i)
+		}
+	}
+	if len(called) != 0 {
+		t.Errorf("snapshotted functions are called: %v", called)
+	}
+	b, err := json.Marshal(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored []concgroup.TaskDescriptor
+	if err := json.Unmarshal(b, &restored); err != nil {
+		t.Fatal(err)
+	}
+	cg2 := new(concgroup.Group)
+	if err := cg2.RestorePending(restored); err == nil {
+		t.Error("restored without the handler")
+	}
+	cg2.Handle("print", handler)
+	if err := cg2.RestorePending(restored); err != nil {
+		t.Fatal(err)
+	}
+	if err := cg2.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(called)
+	if got, want := fmt.Sprint(called), "[0 1 2]"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,212 @@
+package concgroup
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// slowestKeysLen is the number of keys in RunStats.SlowestKeys.
+const slowestKeysLen = 10
+
+// RunStats is the aggregate statistics of the functions called by the Group.
+type RunStats struct {
+	// Started is the time the first function started.
+	Started time.Time
+	// Finished is the time Wait returned. It is zero until then.
+	Finished time.Time
+	// Submitted is the number of functions submitted.
+	Submitted int
+	// Succeeded is the number of functions returned nil.
+	Succeeded int
+	// Failed is the number of functions returned an error.
+	Failed int
+	// Canceled is the number of functions canceled.
+	Canceled int
+	// TotalDuration is the sum of the durations of the function calls.
+	TotalDuration time.Duration
+	// MaxConcurrency is the maximum number of functions running at the same time.
+	MaxConcurrency int
+	// Concurrency is the distribution of the number of running functions over time.
+	// Concurrency[n] is the total time during which exactly n functions were running.
+	Concurrency []time.Duration
+	// Keys is the statistics per key. It has only the 10000 most recently used keys unless SetKeyCacheSize sets the number.
+	Keys map[string]KeyStats
+	// SlowestKeys is the statistics of the keys with the longest function calls, slowest first.
+	SlowestKeys []KeyStats
+}
+
+// KeyStats is the aggregate statistics of the functions with a key.
+type KeyStats struct {
+	// Key is the key.
+	Key string
+	// Count is the number of functions finished.
+	Count int
+	// Failed is the number of functions returned an error.
+	Failed int
+	// TotalDuration is the sum of the durations of the function calls.
+	TotalDuration time.Duration
+	// MaxDuration is the duration of the longest function call.
+	MaxDuration time.Duration
+	// P50 is the median of the durations of the function calls.
+	P50 time.Duration
+	// P95 is the 95th percentile of the durations of the function calls.
+	P95 time.Duration
+	// P99 is the 99th percentile of the durations of the function calls.
+	P99 time.Duration
+}
+
+// keyStats collects KeyStats.
+type keyStats struct {
+	KeyStats
+	durations histogram
+}
+
+// stats collects RunStats.
+type stats struct {
+	mu         sync.Mutex
+	run        RunStats
+	running    int
+	changed    time.Time
+	keys       lru[*keyStats]
+	onComplete []func(RunStats)
+	completed  bool
+}
+
+func (s *stats) submit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.run.Submitted == 0 {
+		s.run.Started = time.Now()
+	}
+	s.run.Submitted++
+}
+
+// start records that a function started running.
+func (s *stats) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changeConcurrency(1)
+}
+
+// stop records that a function stopped running.
+func (s *stats) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changeConcurrency(-1)
+}
+
+func (s *stats) changeConcurrency(delta int) {
+	now := time.Now()
+	s.run.Concurrency = elapse(s.run.Concurrency, s.running, s.changed, now)
+	s.running += delta
+	s.changed = now
+	if s.running > s.run.MaxConcurrency {
+		s.run.MaxConcurrency = s.running
+	}
+}
+
+// elapse adds the time from since to now to h[n].
+func elapse(h []time.Duration, n int, since, now time.Time) []time.Duration {
+	if since.IsZero() {
+		return h
+	}
+	for len(h) <= n {
+		h = append(h, 0)
+	}
+	h[n] += now.Sub(since)
+	return h
+}
+
+func (s *stats) finish(r TaskResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Canceled:
+		s.run.Canceled++
+	case r.Err != nil:
+		s.run.Failed++
+	default:
+		s.run.Succeeded++
+	}
+	s.run.TotalDuration += r.Duration
+	for _, key := range r.Keys {
+		ks := s.keys.get(key, func() *keyStats { return &keyStats{KeyStats: KeyStats{Key: key}} })
+		ks.Count++
+		if r.Err != nil && !r.Canceled {
+			ks.Failed++
+		}
+		ks.TotalDuration += r.Duration
+		if r.Duration > ks.MaxDuration {
+			ks.MaxDuration = r.Duration
+		}
+		if r.Canceled && r.Duration == 0 {
+			continue
+		}
+		ks.durations.record(r.Duration)
+	}
+}
+
+func (s *stats) setKeyCacheSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys.setSize(n)
+}
+
+func (s *stats) addOnComplete(fn func(RunStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onComplete = append(s.onComplete, fn)
+}
+
+// complete calls the OnComplete callbacks once.
+func (s *stats) complete() {
+	s.mu.Lock()
+	if s.completed {
+		s.mu.Unlock()
+		return
+	}
+	s.completed = true
+	s.run.Finished = time.Now()
+	rs := s.snapshot()
+	fns := s.onComplete
+	s.mu.Unlock()
+	for _, fn := range fns {
+		fn(rs)
+	}
+}
+
+func (s *stats) get() RunStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot()
+}
+
+func (s *stats) snapshot() RunStats {
+	rs := s.run
+	now := rs.Finished
+	if now.IsZero() {
+		now = time.Now()
+	}
+	rs.Concurrency = elapse(append([]time.Duration(nil), s.run.Concurrency...), s.running, s.changed, now)
+	rs.Keys = make(map[string]KeyStats, s.keys.len())
+	rs.SlowestKeys = make([]KeyStats, 0, s.keys.len())
+	s.keys.each(func(key string, k *keyStats) {
+		ks := k.KeyStats
+		ks.P50 = k.durations.quantile(0.50)
+		ks.P95 = k.durations.quantile(0.95)
+		ks.P99 = k.durations.quantile(0.99)
+		rs.Keys[key] = ks
+		rs.SlowestKeys = append(rs.SlowestKeys, ks)
+	})
+	sort.Slice(rs.SlowestKeys, func(i, j int) bool {
+		if rs.SlowestKeys[i].MaxDuration != rs.SlowestKeys[j].MaxDuration {
+			return rs.SlowestKeys[i].MaxDuration > rs.SlowestKeys[j].MaxDuration
+		}
+		return rs.SlowestKeys[i].Key < rs.SlowestKeys[j].Key
+	})
+	if len(rs.SlowestKeys) > slowestKeysLen {
+		rs.SlowestKeys = rs.SlowestKeys[:slowestKeysLen]
+	}
+	return rs
+}
@@ -0,0 +1,154 @@
+package concgroup_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestOnComplete(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	called := 0
+	var got concgroup.RunStats
+	cg.OnComplete(func(rs concgroup.RunStats) {
+		called++
+		got = rs
+	})
+	cg.Go("fast", func() error {
+		return nil
+	})
+	cg.Go("slow", func() error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	cg.Go("slow", func() error {
+		return errors.New("task error")
+	})
+	if err := cg.Wait(); err == nil {
+		t.Error("want error")
+	}
+	_ = cg.Wait()
+	if called != 1 {
+		t.Errorf("got %d, want 1", called)
+	}
+	if got.Submitted != 3 || got.Succeeded != 2 || got.Failed != 1 {
+		t.Errorf("got %+v", got)
+	}
+	if got.Finished.IsZero() {
+		t.Error("want finished time")
+	}
+	if len(got.SlowestKeys) != 2 || got.SlowestKeys[0].Key != "slow" {
+		t.Errorf("got %+v, want slow first", got.SlowestKeys)
+	}
+	if ks := got.Keys["slow"]; ks.Count != 2 || ks.Failed != 1 {
+		t.Errorf("got %+v", ks)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.Go("one", func() error {
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cg.Stats(); got.Succeeded != 1 || got.Keys["one"].Count != 1 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestStatsConcurrency(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	for i := 0; i < 3; i++ {
+		cg.Go("samegroup", func() error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+	}
+	for _, key := range []string{"a", "b"} {
+		cg.Go(key, func() error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	got := cg.Stats()
+	if got.MaxConcurrency != 3 {
+		t.Errorf("got %d, want 3", got.MaxConcurrency)
+	}
+	if len(got.Concurrency) != 4 {
+		t.Fatalf("got %v", got.Concurrency)
+	}
+	if got.Concurrency[3] < 40*time.Millisecond {
+		t.Errorf("got %v, want at least 40ms with 3 running functions", got.Concurrency[3])
+	}
+}
+
+func TestStatsPercentiles(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	for i := 0; i < 100; i++ {
+		d := time.Millisecond
+		if i%10 == 0 {
+			d = 30 * time.Millisecond
+		}
+		cg.Go("samegroup", func() error {
+			time.Sleep(d)
+			return nil
+		})
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	ks := cg.Stats().Keys["samegroup"]
+	if ks.P50 < time.Millisecond*97/100 || ks.P50 >= 30*time.Millisecond*97/100 {
+		t.Errorf("got P50 %v", ks.P50)
+	}
+	if ks.P95 < 30*time.Millisecond*97/100 || ks.P99 < ks.P95 {
+		t.Errorf("got P95 %v, P99 %v", ks.P95, ks.P99)
+	}
+}
+
+func TestKeyCacheSize(t *testing.T) {
+	cg := new(concgroup.Group)
+	cg.SetKeyCacheSize(2)
+	for _, key := range []string{"a", "b", "a", "c"} {
+		cg.Go(key, func() error { return nil })
+		if err := cg.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := cg.Stats().Keys
+	if len(keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(keys))
+	}
+	if _, ok := keys["b"]; ok {
+		t.Error("least recently used key b is not evicted")
+	}
+	if got := keys["a"].Count; got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+}
+
+func TestKeyStatsBoundedByDefault(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	for i := 0; i < 10100; i++ {
+		cg.Go(fmt.Sprintf("item-%d", i), func() error { return nil })
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(cg.Stats().Keys); got != 10000 {
+		t.Errorf("got statistics of %d keys, want 10000", got)
+	}
+}
@@ -0,0 +1,102 @@
+// Package statsd provides a StatsD (DogStatsD) metrics sink for concgroup.Group.
+//
+//	sink, err := statsd.Dial("127.0.0.1:8125", "myapp.")
+//	if err != nil {
+//		return err
+//	}
+//	defer sink.Close()
+//	cg.AddEventSink(sink)
+//
+// For every function it emits the counter <prefix>concgroup.tasks and the timer <prefix>concgroup.duration,
+// tagged by key and status (ok, failed or canceled) in the DogStatsD format.
+package statsd
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/k1LoW/concgroup"
+)
+
+// Sink emits the metrics of the results of functions to StatsD.
+type Sink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+// New returns a new Sink writing a packet per result to w, prefixing the metric names with prefix.
+func New(w io.Writer, prefix string) *Sink {
+	return &Sink{w: w, prefix: prefix}
+}
+
+// Dial returns a new Sink sending packets to the StatsD server at addr over UDP.
+func Dial(addr, prefix string) (*Sink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return New(conn, prefix), nil
+}
+
+// Record implements concgroup.EventSink, emitting the metrics of the result of EventFinished.
+func (s *Sink) Record(e concgroup.Event) {
+	if e.Kind != concgroup.EventFinished {
+		return
+	}
+	s.RecordResult(e.Result)
+}
+
+// RecordResult emits the metrics of r. Errors writing to StatsD are ignored as metrics are best effort.
+func (s *Sink) RecordResult(r concgroup.TaskResult) {
+	tags := make([]string, 0, len(r.Keys)+1)
+	for _, key := range r.Keys {
+		tags = append(tags, "key:"+sanitize(key))
+	}
+	tags = append(tags, "status:"+status(r))
+	t := strings.Join(tags, ",")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+	s.buf.WriteString(s.prefix + "concgroup.tasks:1|c|#" + t + "\n")
+	if !r.Canceled || r.Duration > 0 {
+		ms := strconv.FormatFloat(float64(r.Duration.Microseconds())/1000, 'f', -1, 64)
+		s.buf.WriteString(s.prefix + "concgroup.duration:" + ms + "|ms|#" + t + "\n")
+	}
+	_, _ = s.w.Write(s.buf.Bytes())
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (s *Sink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func status(r concgroup.TaskResult) string {
+	switch {
+	case r.Canceled:
+		return "canceled"
+	case r.Err != nil:
+		return "failed"
+	default:
+		return "ok"
+	}
+}
+
+// sanitize replaces the characters reserved by the DogStatsD format in a tag value.
+func sanitize(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '|', ',', '#', '\n', ':':
+			return '_'
+		}
+		return r
+	}, v)
+}
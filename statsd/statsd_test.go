@@ -0,0 +1,69 @@
+package statsd
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		r    concgroup.TaskResult
+		want string
+	}{
+		{
+			concgroup.TaskResult{Keys: []string{"a"}, Duration: 1500 * time.Microsecond},
+			"app.concgroup.tasks:1|c|#key:a,status:ok\napp.concgroup.duration:1.5|ms|#key:a,status:ok\n",
+		},
+		{
+			concgroup.TaskResult{Keys: []string{"a", "b|c"}, Err: errors.New("err"), Duration: 2 * time.Millisecond},
+			"app.concgroup.tasks:1|c|#key:a,key:b_c,status:failed\napp.concgroup.duration:2|ms|#key:a,key:b_c,status:failed\n",
+		},
+		{
+			concgroup.TaskResult{Keys: []string{"a"}, Err: concgroup.ErrCanceled, Canceled: true},
+			"app.concgroup.tasks:1|c|#key:a,status:canceled\n",
+		},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		New(buf, "app.").RecordResult(tt.r)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDial(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	sink, err := Dial(pc.LocalAddr().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	cg := new(concgroup.Group)
+	cg.AddEventSink(sink)
+	cg.Go("a", func() error { return nil })
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 1024)
+	if err := pc.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b[:n]); !strings.HasPrefix(got, "concgroup.tasks:1|c|#key:a,status:ok\n") {
+		t.Errorf("got %q", got)
+	}
+}
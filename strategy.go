@@ -0,0 +1,101 @@
+package concgroup
+
+// Strategy is a strategy for acquiring the locks of multiple keys in GoMulti and TryGoMulti.
+// It is a closed set implemented only by this package: use one of the strategies below with SetStrategy.
+type Strategy interface {
+	// acquire tries to take the locks of the keys of t.
+	// It returns true when t holds all of them, otherwise t is queued as a waiter of a key.
+	acquire(lt *lockTable, t *task) bool
+}
+
+var (
+	// SortedOrder acquires the locks one by one in sorted key order, holding the acquired ones while waiting for the rest.
+	// It is the default strategy.
+	SortedOrder Strategy = sortedOrder{}
+	// AllOrNothing acquires the locks only when all of them are available at once, holding none of them while waiting.
+	// It increases throughput when keys overlap heavily, but a goroutine with many keys may wait longer:
+	// as it waits for one key at a time, younger goroutines can keep taking its other keys under contention.
+	AllOrNothing Strategy = allOrNothing{}
+	// WaitDie acquires the locks in sorted key order. A goroutine waits for a lock held by a younger goroutine,
+	// and releases all its locks and starts over ("dies") when the lock is held by an older one.
+	WaitDie Strategy = waitDie{}
+	// WoundWait acquires the locks in sorted key order. A goroutine forces a younger goroutine that is still acquiring
+	// a lock it needs to release all its locks and start over ("wounds" it), and waits for a lock held by an older one.
+	WoundWait Strategy = woundWait{}
+)
+
+type sortedOrder struct{}
+
+func (sortedOrder) acquire(lt *lockTable, t *task) bool {
+	for _, key := range t.order {
+		if lt.holds(t, key) {
+			continue
+		}
+		if !lt.tryTake(key, t) {
+			lt.wait(key, t)
+			return false
+		}
+	}
+	return true
+}
+
+type allOrNothing struct{}
+
+func (allOrNothing) acquire(lt *lockTable, t *task) bool {
+	for _, key := range t.order {
+		if !lt.holds(t, key) && !lt.available(key, t) {
+			lt.wait(key, t)
+			return false
+		}
+	}
+	for _, key := range t.order {
+		lt.take(key, t)
+	}
+	return true
+}
+
+type waitDie struct{}
+
+func (waitDie) acquire(lt *lockTable, t *task) bool {
+	for _, key := range t.order {
+		if lt.holds(t, key) {
+			continue
+		}
+		if lt.tryTake(key, t) {
+			continue
+		}
+		for _, h := range lt.blockers(key, t) {
+			if h.seq < t.seq {
+				// Die, and start over after the older holder releases the lock.
+				lt.releaseAll(t)
+				break
+			}
+		}
+		lt.wait(key, t)
+		return false
+	}
+	return true
+}
+
+type woundWait struct{}
+
+func (woundWait) acquire(lt *lockTable, t *task) bool {
+	for _, key := range t.order {
+		if lt.holds(t, key) {
+			continue
+		}
+		if lt.tryTake(key, t) {
+			continue
+		}
+		for _, h := range lt.blockers(key, t) {
+			if t.seq < h.seq && !h.running {
+				// Wound the younger holder still acquiring locks.
+				h.wounded = true
+				wake(h)
+			}
+		}
+		lt.wait(key, t)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package concgroup_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+var strategies = []struct {
+	name     string
+	strategy concgroup.Strategy
+}{
+	{"SortedOrder", concgroup.SortedOrder},
+	{"AllOrNothing", concgroup.AllOrNothing},
+	{"WaitDie", concgroup.WaitDie},
+	{"WoundWait", concgroup.WoundWait},
+}
+
+func TestStrategyExclusive(t *testing.T) {
+	for _, s := range strategies {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			t.Parallel()
+			cg := new(concgroup.Group)
+			cg.SetStrategy(s.strategy)
+			mu := sync.Mutex{}
+			running := map[string]bool{}
+			for i := 0; i < 100; i++ {
+				keys := []string{fmt.Sprintf("a-%d", i%5), fmt.Sprintf("b-%d", (i*3+1)%7), fmt.Sprintf("c-%d", (i*7+2)%11)}
+				cg.GoMulti(keys, func() error {
+					mu.Lock()
+					for _, key := range keys {
+						if running[key] {
+							mu.Unlock()
+							return errors.New("violate group concurrency")
+						}
+						running[key] = true
+					}
+					mu.Unlock()
+					time.Sleep(time.Millisecond)
+					mu.Lock()
+					for _, key := range keys {
+						delete(running, key)
+					}
+					mu.Unlock()
+					return nil
+				})
+			}
+			if err := cg.Wait(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestStrategyAvoidDeadlock(t *testing.T) {
+	for _, s := range strategies {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			t.Parallel()
+			for i := 0; i < 100; i++ {
+				mu := sync.Mutex{}
+				cg := new(concgroup.Group)
+				cg.SetStrategy(s.strategy)
+				mu.Lock()
+				cg.GoMulti([]string{"A", "B", "C"}, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					return nil
+				})
+				cg.GoMulti([]string{"A", "C"}, func() error {
+					return nil
+				})
+				cg.GoMulti([]string{"C", "B"}, func() error {
+					return nil
+				})
+				cg.GoMulti([]string{"B", "A"}, func() error {
+					return nil
+				})
+				mu.Unlock()
+				if err := cg.Wait(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestAllOrNothingDoesNotHoldWhileWaiting(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetStrategy(concgroup.AllOrNothing)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("B", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	cg.GoMulti([]string{"A", "B"}, func() error {
+		return nil
+	})
+	done := make(chan struct{})
+	// "A" must not be held by the goroutine waiting for "B".
+	cg.Go("A", func() error {
+		close(done)
+		return nil
+	})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("key A is held while waiting for key B")
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,98 @@
+package concgroup
+
+// Open makes the group open-ended: Wait does not return until Close is called and all function calls have returned,
+// so that producers can keep calling Go while a consumer is already blocked in Wait.
+// It must be called before Wait.
+func (g *Group) Open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.init()
+	if g.opened != nil || g.closed {
+		return
+	}
+	opened := make(chan struct{})
+	g.opened = opened
+	g.eg.Go(func() error {
+		<-opened
+		return nil
+	})
+}
+
+// Close marks that no more functions will be submitted to the group.
+// Functions submitted after Close are not called, and their results have ErrGroupClosed.
+// Wait of an open-ended group returns once the functions submitted before Close have returned.
+func (g *Group) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.init()
+	g.close()
+}
+
+// SetCloseOnCancel sets whether the group is closed when its context is canceled.
+// When enabled, functions submitted after the context of WithContext is canceled are not called, and their results
+// have ErrGroupClosed, so that a canceled request cannot keep enqueueing work that will never run usefully.
+func (g *Group) SetCloseOnCancel(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.init()
+	watch := on && !g.closeOnCancel
+	g.closeOnCancel = on
+	if watch {
+		g.watchCancel()
+	}
+}
+
+// watchCancel closes the group when the context of the current run is canceled in close-on-cancel mode.
+// g.mu must be held.
+func (g *Group) watchCancel() {
+	ctx := g.ctx
+	if ctx.Done() == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closeOnCancel && g.ctx == ctx {
+			g.close()
+		}
+	}()
+}
+
+// isClosed reports whether the group is closed, or its context is canceled in close-on-cancel mode.
+// g.mu must be held at least for reading.
+func (g *Group) isClosed() bool {
+	return g.closed || (g.closeOnCancel && g.ctx.Err() != nil)
+}
+
+// close closes the group. g.mu must be held.
+func (g *Group) close() {
+	g.closed = true
+	if g.opened != nil {
+		close(g.opened)
+		g.opened = nil
+	}
+}
+
+// Daemon makes the group run as a daemon for never-ending keyed consumers.
+// Errors of functions are passed to onError as they occur instead of being returned by Wait (or canceling the context
+// of the group), and the group is open-ended until its context is canceled or it is closed by Close or Drain.
+// A panic of a function is recovered and passed to onError as a *RecoveredPanic instead of repanicking in Wait.
+// It must be called before any function is submitted.
+func (g *Group) Daemon(onError func(r TaskResult)) {
+	g.Open()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onError = onError
+	opened := g.opened
+	if opened == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-g.ctx.Done():
+			g.Close()
+		case <-opened:
+		}
+	}()
+}
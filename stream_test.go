@@ -0,0 +1,167 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestOpenClose(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.Open()
+	waited := make(chan error)
+	go func() {
+		waited <- cg.Wait()
+	}()
+	var called int64
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				cg.Go(fmt.Sprintf("producer-%d", i), func() error {
+					atomic.AddInt64(&called, 1)
+					return nil
+				})
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned before Close")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cg.Close()
+	if err := <-waited; err != nil {
+		t.Error(err)
+	}
+	if got := atomic.LoadInt64(&called); got != 40 {
+		t.Errorf("got %d, want 40", got)
+	}
+	if cg.TryGo("late", func() error { return nil }) {
+		t.Error("function is submitted after Close")
+	}
+}
+
+func TestCloseRejects(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	ch := cg.Subscribe("late")
+	cg.Close()
+	cg.Go("late", func() error {
+		t.Error("function submitted after Close is called")
+		return nil
+	})
+	go func() {
+		_ = cg.Wait()
+	}()
+	r := <-ch
+	if !errors.Is(r.Err, concgroup.ErrGroupClosed) {
+		t.Errorf("got %v, want %v", r.Err, concgroup.ErrGroupClosed)
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cg, gctx := concgroup.WithContext(ctx)
+	errs := make(chan concgroup.TaskResult, 10)
+	cg.Daemon(func(r concgroup.TaskResult) {
+		errs <- r
+	})
+	waited := make(chan error)
+	go func() {
+		waited <- cg.Wait()
+	}()
+	cg.Go("tenant-a", func() error {
+		return errors.New("task error")
+	})
+	r := <-errs
+	if r.Keys[0] != "tenant-a" {
+		t.Errorf("got %v, want tenant-a", r.Keys)
+	}
+	if gctx.Err() != nil {
+		t.Error("context of the group is canceled by a function error")
+	}
+	called := make(chan struct{})
+	cg.Go("tenant-b", func() error {
+		close(called)
+		return nil
+	})
+	<-called
+	cancel()
+	if err := <-waited; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDaemonPanic(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cg, _ := concgroup.WithContext(ctx)
+	errs := make(chan concgroup.TaskResult, 10)
+	cg.Daemon(func(r concgroup.TaskResult) {
+		errs <- r
+	})
+	waited := make(chan error)
+	go func() {
+		waited <- cg.Wait()
+	}()
+	cg.Go("tenant-a", func() error {
+		panic("boom")
+	})
+	r := <-errs
+	var p *concgroup.RecoveredPanic
+	if !errors.As(r.Err, &p) {
+		t.Fatalf("got %v, want *concgroup.RecoveredPanic", r.Err)
+	}
+	if p.Value != "boom" {
+		t.Errorf("got %v, want boom", p.Value)
+	}
+	cancel()
+	if err := <-waited; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetCloseOnCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cg, _ := concgroup.WithContext(ctx)
+	cg.SetCloseOnCancel(true)
+	ch := cg.Subscribe("samegroup")
+	cg.Go("samegroup", func() error {
+		return nil
+	})
+	cancel()
+	cg.Go("samegroup", func() error {
+		t.Error("function submitted after the context is canceled is called")
+		return nil
+	})
+	if cg.TryGo("samegroup", func() error { return nil }) {
+		t.Error("function is submitted after the context is canceled")
+	}
+	go func() {
+		_ = cg.Wait()
+	}()
+	var closed bool
+	for r := range ch {
+		if errors.Is(r.Err, concgroup.ErrGroupClosed) {
+			closed = true
+		}
+	}
+	if !closed {
+		t.Error("want result with ErrGroupClosed")
+	}
+}
@@ -0,0 +1,21 @@
+package concgroup
+
+import (
+	"golang.org/x/sync/errgroup"
+)
+
+// Subgroup returns a new Group whose key locks are shared with g, for a phase of work that must be awaited on its own
+// while holding off the functions of g with the same keys. A function of the subgroup with a key waits for
+// the functions of g (and of the other subgroups of g) with the key, and vice versa.
+// The per-key settings of g such as SetKeyLimit, SetMaxKeys and PauseKey apply to the subgroup as well,
+// and so does the KeyLocker set by SetKeyLocker or SetLockerFactory on g before the subgroup is created,
+// and the other settings, the limit of goroutines, the error and Wait are its own:
+// an error of the subgroup does not cancel g. The context of the subgroup is derived from the context of g,
+// and like the one of WithContext, it is canceled when Wait of the subgroup returns.
+func (g *Group) Subgroup() *Group {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	g.init()
+	eg, ctx := errgroup.WithContext(g.ctx)
+	return &Group{eg: eg, ctx: ctx, parent: g.ctx, locks: g.locks, locker: g.locker}
+}
@@ -0,0 +1,86 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestSubgroup(t *testing.T) {
+	cg := &concgroup.Group{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var parentDone atomic.Bool
+	cg.Go("key", func() error {
+		close(started)
+		<-release
+		parentDone.Store(true)
+		return nil
+	})
+	<-started
+
+	sub := cg.Subgroup()
+	sub.Go("other", func() error {
+		return nil
+	})
+	if err := sub.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	var ranAfterParent atomic.Bool
+	sub = cg.Subgroup()
+	sub.Go("key", func() error {
+		ranAfterParent.Store(parentDone.Load())
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := sub.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !ranAfterParent.Load() {
+		t.Error("the function of the subgroup ran while the function of the group held the key")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubgroupError(t *testing.T) {
+	cg, ctx := concgroup.WithContext(context.Background())
+	sub := cg.Subgroup()
+	want := errors.New("failed")
+	sub.Go("key", func() error {
+		return want
+	})
+	if err := sub.Wait(); !errors.Is(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+	if ctx.Err() != nil {
+		t.Error("the error of the subgroup canceled the group")
+	}
+	cg.Go("key", func() error {
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubgroupKeyLocker(t *testing.T) {
+	l := &recordingLocker{held: map[string]bool{}}
+	cg := &concgroup.Group{}
+	cg.SetKeyLocker(l)
+	sub := cg.Subgroup()
+	sub.Go("a", func() error { return nil })
+	if err := sub.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(l.acquired); got != 1 {
+		t.Errorf("got %d acquisitions by the locker of the group, want 1", got)
+	}
+}
@@ -0,0 +1,36 @@
+package concgroup
+
+import (
+	"context"
+)
+
+// GoTagged calls the given function in a new goroutine like Go with tags.
+// Tags are independent of keys, and functions can be canceled by tag with CancelTags.
+func (g *Group) GoTagged(key string, tags []string, f func() error) {
+	g.submit(spec{keys: []string{key}, tags: tags}, Adapt(f))
+}
+
+// GoMultiTagged calls the given function in a new goroutine like GoMulti with tags.
+func (g *Group) GoMultiTagged(keys []string, tags []string, f func() error) {
+	g.submit(spec{keys: sortedKeys(keys), tags: tags}, Adapt(f))
+}
+
+// GoTaggedCtx calls the given function in a new goroutine like GoCtx with tags.
+func (g *Group) GoTaggedCtx(key string, tags []string, f func(ctx context.Context) error) {
+	g.submit(spec{keys: []string{key}, tags: tags}, f)
+}
+
+// CancelTags cancels the functions with any of tags like CancelKeys.
+func (g *Group) CancelTags(tags ...string) {
+	g.init()
+	g.locks.cancel(g, func(t *task) bool {
+		for _, tag := range t.tags {
+			for _, tt := range tags {
+				if tag == tt {
+					return true
+				}
+			}
+		}
+		return false
+	}, ErrCanceled)
+}
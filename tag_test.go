@@ -0,0 +1,47 @@
+package concgroup_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestCancelTags(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.GoTaggedCtx("db", []string{"request-1"}, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	mu := sync.Mutex{}
+	var called []string
+	record := func(name string) func() error {
+		return func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			called = append(called, name)
+			return nil
+		}
+	}
+	cg.Go("api", func() error {
+		<-block
+		return nil
+	})
+	cg.GoTagged("db", []string{"request-1"}, record("request-1 db"))
+	cg.GoMultiTagged([]string{"api", "cache"}, []string{"request-1"}, record("request-1 api"))
+	cg.GoTagged("db", []string{"request-2"}, record("request-2 db"))
+	cg.CancelTags("request-1")
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Error(err)
+	}
+	if len(called) != 1 || called[0] != "request-2 db" {
+		t.Errorf("got %v, want [request-2 db]", called)
+	}
+}
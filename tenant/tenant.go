@@ -0,0 +1,236 @@
+// Package tenant provides a multi-tenant scheduler on top of concgroup.Group.
+//
+// A Scheduler runs functions of tenants with per-tenant concurrency and rate limits,
+// sharing its concurrency between the tenants by weighted fairness, so that a busy tenant does not starve the others.
+// Functions are still serialized by key by the underlying Group.
+package tenant
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+// Config is the configuration of a tenant.
+type Config struct {
+	// Concurrency is the maximum number of running functions of the tenant. Zero indicates no limit.
+	Concurrency int
+	// Rate is the maximum number of functions of the tenant started per second. Zero indicates no limit.
+	Rate float64
+	// Weight is the share of the tenant in the concurrency of the Scheduler relative to the other tenants. Zero is treated as 1.
+	Weight int
+}
+
+// Scheduler is a multi-tenant scheduler of functions.
+type Scheduler struct {
+	cg       *concgroup.Group
+	mu       sync.Mutex
+	limit    int
+	running  int
+	defaults Config
+	// tenants is the state of the tenants with their own configuration or functions queued, running or limited by Rate.
+	tenants map[string]*tenant
+	// vtime is the virtual time of the fairness, the minimum virtual time of the tenants with queued functions.
+	vtime float64
+	// timer dispatches again for the tenants limited by Rate. It is stopped when none of them has queued functions.
+	timer *time.Timer
+	// queued counts functions not yet finished, whether they are called or not.
+	queued sync.WaitGroup
+}
+
+type tenant struct {
+	config     Config
+	configured bool
+	queue      []job
+	running    int
+	// vtime is the virtual time of the tenant, advancing by 1/weight per started function.
+	vtime float64
+	// next is the time the next function of the tenant can start by Rate.
+	next time.Time
+}
+
+type job struct {
+	key string
+	f   func(ctx context.Context) error
+}
+
+// New returns a new Scheduler running functions in cg.
+func New(cg *concgroup.Group) *Scheduler {
+	return &Scheduler{
+		cg:      cg,
+		tenants: map[string]*tenant{},
+	}
+}
+
+// SetLimit limits the number of running functions of all tenants to at most n, shared between the tenants by weight.
+// Zero or a negative value indicates no limit.
+func (s *Scheduler) SetLimit(n int) {
+	s.mu.Lock()
+	s.limit = n
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+// SetDefault sets the configuration of the tenants without their own configuration.
+func (s *Scheduler) SetDefault(c Config) {
+	s.mu.Lock()
+	s.defaults = c
+	for _, t := range s.tenants {
+		if !t.configured {
+			t.config = c
+		}
+	}
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+// SetTenant sets the configuration of tenant.
+func (s *Scheduler) SetTenant(tenant string, c Config) {
+	s.mu.Lock()
+	t := s.tenant(tenant)
+	t.config = c
+	t.configured = true
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+// Go queues the given function of tenant, and calls it with key like concgroup.Group.GoCtx when the limits allow.
+func (s *Scheduler) Go(tenant, key string, f func(ctx context.Context) error) {
+	s.queued.Add(1)
+	s.mu.Lock()
+	t := s.tenant(tenant)
+	if len(t.queue) == 0 && t.vtime < s.vtime {
+		// An idle tenant does not accumulate credit.
+		t.vtime = s.vtime
+	}
+	t.queue = append(t.queue, job{key: key, f: f})
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+// Wait blocks until all the queued functions have returned or been canceled without being called, like concgroup.Group.Wait.
+func (s *Scheduler) Wait() error {
+	s.queued.Wait()
+	return s.cg.Wait()
+}
+
+// tenant returns the state of name, creating it if necessary. s.mu must be held.
+func (s *Scheduler) tenant(name string) *tenant {
+	t, ok := s.tenants[name]
+	if !ok {
+		t = &tenant{config: s.defaults}
+		s.tenants[name] = t
+	}
+	return t
+}
+
+// dispatch submits the queued functions the limits allow to cg, the tenant with the smallest virtual time first.
+func (s *Scheduler) dispatch() {
+	var (
+		jobs    []job
+		tenants []*tenant
+	)
+	s.mu.Lock()
+	now := time.Now()
+	var wakeAt time.Time
+	s.forget(now)
+	for s.limit <= 0 || s.running < s.limit {
+		var next *tenant
+		for _, t := range s.tenants {
+			if len(t.queue) == 0 {
+				continue
+			}
+			if t.config.Concurrency > 0 && t.running >= t.config.Concurrency {
+				continue
+			}
+			if now.Before(t.next) {
+				if wakeAt.IsZero() || t.next.Before(wakeAt) {
+					wakeAt = t.next
+				}
+				continue
+			}
+			if next == nil || t.vtime < next.vtime {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+		j := next.queue[0]
+		next.queue = next.queue[1:]
+		next.running++
+		s.running++
+		weight := next.config.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		next.vtime += 1 / float64(weight)
+		if next.config.Rate > 0 {
+			if next.next.Before(now) {
+				next.next = now
+			}
+			next.next = next.next.Add(time.Duration(float64(time.Second) / next.config.Rate))
+		}
+		jobs = append(jobs, j)
+		tenants = append(tenants, next)
+	}
+	s.advance()
+	if !wakeAt.IsZero() {
+		s.wakeAt(wakeAt.Sub(now))
+	} else if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.mu.Unlock()
+	for i, j := range jobs {
+		t := tenants[i]
+		// The group may cancel or skip the function without calling it, so it is finished by its handle.
+		h := s.cg.GoHandleCtx(j.key, j.f)
+		go func() {
+			<-h.Done()
+			s.finish(t)
+		}()
+	}
+}
+
+// forget drops the state of the idle tenants without their own configuration, which is the same as a new state.
+// A tenant limited by Rate is idle once its next function can start. s.mu must be held.
+func (s *Scheduler) forget(now time.Time) {
+	for name, t := range s.tenants {
+		if !t.configured && len(t.queue) == 0 && t.running == 0 && !now.Before(t.next) {
+			delete(s.tenants, name)
+		}
+	}
+}
+
+// advance sets the virtual time of s to the minimum virtual time of the tenants with queued functions. s.mu must be held.
+func (s *Scheduler) advance() {
+	min := -1.0
+	for _, t := range s.tenants {
+		if len(t.queue) > 0 && (min < 0 || t.vtime < min) {
+			min = t.vtime
+		}
+	}
+	if min > s.vtime {
+		s.vtime = min
+	}
+}
+
+// wakeAt dispatches again after d for a tenant limited by Rate. s.mu must be held.
+func (s *Scheduler) wakeAt(d time.Duration) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(d, s.dispatch)
+}
+
+func (s *Scheduler) finish(t *tenant) {
+	s.mu.Lock()
+	t.running--
+	s.running--
+	s.mu.Unlock()
+	s.dispatch()
+	s.queued.Done()
+}
@@ -0,0 +1,153 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestConcurrency(t *testing.T) {
+	t.Parallel()
+	s := New(new(concgroup.Group))
+	s.SetDefault(Config{Concurrency: 2})
+	var running, maxRunning int64
+	for i := 0; i < 10; i++ {
+		s.Go("a", fmt.Sprintf("key-%d", i), func(ctx context.Context) error {
+			n := atomic.AddInt64(&running, 1)
+			defer atomic.AddInt64(&running, -1)
+			for {
+				m := atomic.LoadInt64(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	}
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning != 2 {
+		t.Errorf("got max %d running functions, want 2", maxRunning)
+	}
+}
+
+func TestRate(t *testing.T) {
+	t.Parallel()
+	s := New(new(concgroup.Group))
+	s.SetTenant("a", Config{Rate: 50})
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		s.Go("a", fmt.Sprintf("key-%d", i), func(ctx context.Context) error { return nil })
+	}
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	// 6 functions at 50 per second take at least 100ms after the first.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("got %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestWeightedFairness(t *testing.T) {
+	t.Parallel()
+	s := New(new(concgroup.Group))
+	s.SetLimit(1)
+	s.SetTenant("heavy", Config{Weight: 2})
+	s.SetTenant("light", Config{Weight: 1})
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	block := make(chan struct{})
+	// Occupy the limit while queueing so that the order is decided by fairness.
+	s.Go("blocker", "blocker", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+	for i := 0; i < 6; i++ {
+		for _, name := range []string{"heavy", "light"} {
+			name := name
+			s.Go(name, fmt.Sprintf("%s-%d", name, i), func(ctx context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			})
+		}
+	}
+	close(block)
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	heavy := 0
+	for _, name := range order[:6] {
+		if name == "heavy" {
+			heavy++
+		}
+	}
+	if heavy != 4 {
+		t.Errorf("got %d of heavy in the first 6 of %v, want 4", heavy, order)
+	}
+}
+
+func TestRateWait(t *testing.T) {
+	t.Parallel()
+	s := New(new(concgroup.Group))
+	s.SetDefault(Config{Rate: 50})
+	var called int64
+	for i := 0; i < 3; i++ {
+		s.Go(fmt.Sprintf("tenant-%d", i%2), "key", func(ctx context.Context) error {
+			atomic.AddInt64(&called, 1)
+			return nil
+		})
+	}
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt64(&called); got != 3 {
+		t.Errorf("got %d functions called before Wait returned, want 3", got)
+	}
+	s.mu.Lock()
+	if s.timer != nil {
+		t.Error("the timer of Rate is left after Wait returned")
+	}
+	s.mu.Unlock()
+	// The tenants are idle once their next functions can start.
+	time.Sleep(50 * time.Millisecond)
+	s.dispatch()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.tenants); n != 0 {
+		t.Errorf("got %d tenants left, want 0", n)
+	}
+}
+
+func TestCanceledBeforeCall(t *testing.T) {
+	t.Parallel()
+	cg, _ := concgroup.WithContext(context.Background())
+	s := New(cg)
+	s.SetLimit(1)
+	errTask := errors.New("task error")
+	s.Go("a", "key", func(ctx context.Context) error { return errTask })
+	// The context of the group is canceled by the error, so the following functions are not called.
+	s.Go("a", "key", func(ctx context.Context) error { return nil })
+	s.Go("b", "other", func(ctx context.Context) error { return nil })
+	done := make(chan error)
+	go func() { done <- s.Wait() }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, errTask) {
+			t.Errorf("got %v, want %v", err, errTask)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait is blocked by the functions canceled before being called")
+	}
+}
@@ -0,0 +1,48 @@
+package concgroup
+
+import (
+	"context"
+	"time"
+)
+
+// PprofKeyLabel is the pprof label set to the keys of a function, joined by ",", while it is called.
+// It lets CPU profiles and goroutine dumps be attributed to keys.
+const PprofKeyLabel = "concgroup.key"
+
+// Tracer traces the calls of functions, such as an adapter to OpenTelemetry:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) StartTask(ctx context.Context, keys []string, submitted time.Time) (context.Context, func(error)) {
+//		ctx, span := t.Start(ctx, strings.Join(keys, ","), trace.WithTimestamp(submitted))
+//		span.AddEvent("started")
+//		return ctx, func(err error) {
+//			if err != nil {
+//				span.RecordError(err)
+//				span.SetStatus(codes.Error, err.Error())
+//			}
+//			span.End()
+//		}
+//	}
+type Tracer interface {
+	// StartTask starts tracing a function with keys submitted at submitted, which is called now after waiting
+	// for the key locks and the limits. ctx is the context of the function, derived from the context of
+	// the group given to WithContext so that it carries the span of the submitter.
+	// The function is called with the returned context, and end is called with its error when it returns.
+	StartTask(ctx context.Context, keys []string, submitted time.Time) (_ context.Context, end func(err error))
+}
+
+// SetTracer sets tr to trace the calls of functions. It must be called before any function is submitted.
+func (g *Group) SetTracer(tr Tracer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tracer = tr
+}
+
+// startTask starts tracing the call of t, returning the context to call it with and the function to end tracing.
+func (g *Group) startTask(t *task) (context.Context, func(err error)) {
+	if g.tracer == nil {
+		return t.ctx, func(error) {}
+	}
+	return g.tracer.StartTask(t.ctx, t.keys, t.submitted)
+}
@@ -0,0 +1,102 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+type ctxKey string
+
+type span struct {
+	name      string
+	submitted time.Time
+	started   time.Time
+	parent    any
+	err       error
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+func (tr *testTracer) StartTask(ctx context.Context, keys []string, submitted time.Time) (context.Context, func(error)) {
+	s := &span{name: fmt.Sprint(keys), submitted: submitted, started: time.Now(), parent: ctx.Value(ctxKey("span"))}
+	return context.WithValue(ctx, ctxKey("span"), s), func(err error) {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+		s.err = err
+		tr.spans = append(tr.spans, s)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+	ctx := context.WithValue(context.Background(), ctxKey("span"), "submitter")
+	cg, _ := concgroup.WithContext(ctx)
+	tr := &testTracer{}
+	cg.SetTracer(tr)
+	errTask := errors.New("task error")
+	cg.GoCtx("tenant-a", func(ctx context.Context) error {
+		if _, ok := ctx.Value(ctxKey("span")).(*span); !ok {
+			t.Error("function is not called with the context of the span")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return errTask
+	})
+	if err := cg.Wait(); !errors.Is(err, errTask) {
+		t.Fatalf("got %v, want %v", err, errTask)
+	}
+	if len(tr.spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(tr.spans))
+	}
+	s := tr.spans[0]
+	if s.name != "[tenant-a]" {
+		t.Errorf("got span %s, want [tenant-a]", s.name)
+	}
+	if s.parent != "submitter" {
+		t.Errorf("got parent %v, want submitter", s.parent)
+	}
+	if s.submitted.IsZero() || s.submitted.After(s.started) {
+		t.Errorf("got submitted at %v, want before started at %v", s.submitted, s.started)
+	}
+	if !errors.Is(s.err, errTask) {
+		t.Errorf("got %v, want %v", s.err, errTask)
+	}
+}
+
+func TestPprofLabels(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	labels := make(chan string, 2)
+	cg.GoCtx("tenant-a", func(ctx context.Context) error {
+		v, _ := pprof.Label(ctx, concgroup.PprofKeyLabel)
+		labels <- v
+		return nil
+	})
+	cg.GoMultiCtx([]string{"tenant-c", "tenant-b"}, func(ctx context.Context) error {
+		v, _ := pprof.Label(ctx, concgroup.PprofKeyLabel)
+		labels <- v
+		return nil
+	})
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	close(labels)
+	got := map[string]bool{}
+	for v := range labels {
+		got[v] = true
+	}
+	for _, want := range []string{"tenant-a", "tenant-b,tenant-c"} {
+		if !got[want] {
+			t.Errorf("got labels %v, want %q", got, want)
+		}
+	}
+}
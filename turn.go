@@ -0,0 +1,142 @@
+package concgroup
+
+import (
+	"strings"
+	"sync"
+)
+
+// LimitPolicy is the policy of the limit set by SetLimit.
+type LimitPolicy int
+
+const (
+	// LimitGoroutines counts the active goroutines like errgroup.Group: a function takes its slots when it is submitted,
+	// blocking the submission until they are available, and holds them while waiting for its key locks.
+	// It is the default policy.
+	LimitGoroutines LimitPolicy = iota
+	// LimitRoundRobin counts the running functions: a function takes its slots once it holds its key locks, and the
+	// available slots are handed out to the keys in turn, so that a key with many queued functions does not
+	// monopolize the limit and every key makes progress. Submissions do not block on the limit, and TryGo accepts
+	// a function if its slots are available at the time without reserving them.
+	LimitRoundRobin
+)
+
+// SetLimitPolicy sets the policy of the limit set by SetLimit. It must be called before any function is submitted.
+func (g *Group) SetLimitPolicy(p LimitPolicy) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limitPolicy = p
+}
+
+// releaseSlots releases the slots of the limit taken by the submission of s.
+// The slots of a function replaying a schedule are released as well, as they are taken when its turn comes.
+func (g *Group) releaseSlots(s *spec) {
+	if !s.roundRobin {
+		g.limiter.release(s.slotsOf())
+	}
+}
+
+// turns hands out the slots of the limit to the functions of keys in turn in LimitRoundRobin.
+type turns struct {
+	mu sync.Mutex
+	// queues is the functions waiting for slots per lane, the keys of a function.
+	queues map[string][]*turn
+	// ring is the lanes with waiting functions in the order of their turns.
+	ring []string
+}
+
+// turn is a function waiting for slots.
+type turn struct {
+	lane    string
+	w       int
+	granted chan struct{}
+}
+
+// wait blocks until t takes its slots of l, and returns the cause if t is canceled before that.
+func (ts *turns) wait(l *limiter, lt *lockTable, t *task) error {
+	tn := &turn{lane: strings.Join(t.keys, "\x00"), w: t.slotsOf(), granted: make(chan struct{})}
+	ts.mu.Lock()
+	if ts.queues == nil {
+		ts.queues = map[string][]*turn{}
+	}
+	if len(ts.queues[tn.lane]) == 0 {
+		ts.ring = append(ts.ring, tn.lane)
+	}
+	ts.queues[tn.lane] = append(ts.queues[tn.lane], tn)
+	ts.dispatch(l)
+	ts.mu.Unlock()
+	select {
+	case <-tn.granted:
+		return nil
+	case <-t.ctx.Done():
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	select {
+	case <-tn.granted:
+		// Granted at the same time: give the slots back to the others.
+		l.release(tn.w)
+		ts.dispatch(l)
+	default:
+		ts.remove(tn)
+	}
+	return lt.canceledBy(t)
+}
+
+// done releases the slots of t.
+func (ts *turns) done(l *limiter, t *task) {
+	l.release(t.slotsOf())
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.dispatch(l)
+}
+
+// notify grants slots to the waiting functions after the limit is changed.
+func (ts *turns) notify(l *limiter) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.dispatch(l)
+}
+
+// dispatch grants slots to the first waiting function of each lane in turn until the next one does not fit.
+// ts.mu must be held.
+func (ts *turns) dispatch(l *limiter) {
+	for len(ts.ring) > 0 {
+		lane := ts.ring[0]
+		q := ts.queues[lane]
+		tn := q[0]
+		if !l.tryAcquire(tn.w) {
+			return
+		}
+		close(tn.granted)
+		ts.ring = ts.ring[1:]
+		if len(q) == 1 {
+			delete(ts.queues, lane)
+			continue
+		}
+		ts.queues[lane] = q[1:]
+		// The lane waits for its next turn behind the others.
+		ts.ring = append(ts.ring, lane)
+	}
+}
+
+// remove removes tn giving up waiting. ts.mu must be held.
+func (ts *turns) remove(tn *turn) {
+	q := ts.queues[tn.lane]
+	for i, o := range q {
+		if o == tn {
+			q = append(q[:i:i], q[i+1:]...)
+			break
+		}
+	}
+	if len(q) > 0 {
+		ts.queues[tn.lane] = q
+		return
+	}
+	delete(ts.queues, tn.lane)
+	for i, lane := range ts.ring {
+		if lane == tn.lane {
+			ts.ring = append(ts.ring[:i:i], ts.ring[i+1:]...)
+			break
+		}
+	}
+}
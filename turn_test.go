@@ -0,0 +1,104 @@
+package concgroup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestLimitRoundRobin(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetLimit(2)
+	cg.SetLimitPolicy(concgroup.LimitRoundRobin)
+	cg.SetKeyLimit("hot", 100)
+	var mu sync.Mutex
+	var started []string
+	run := func(key string) func() error {
+		return func() error {
+			mu.Lock()
+			started = append(started, key)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			return nil
+		}
+	}
+	for i := 0; i < 40; i++ {
+		cg.Go("hot", run("hot"))
+	}
+	for i := 0; i < 4; i++ {
+		key := fmt.Sprintf("cold-%d", i)
+		cg.Go(key, run(key))
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	last := 0
+	for i, key := range started {
+		if key != "hot" {
+			last = i
+		}
+	}
+	if last >= 20 {
+		t.Errorf("the last function with another key started %dth behind the hot key, want them to take turns: %v", last+1, started)
+	}
+}
+
+func TestLimitRoundRobinTryGo(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetLimit(1)
+	cg.SetLimitPolicy(concgroup.LimitRoundRobin)
+	block := make(chan struct{})
+	started := make(chan struct{})
+	cg.Go("a", func() error {
+		close(started)
+		<-block
+		return nil
+	})
+	<-started
+	if cg.TryGo("b", func() error { return nil }) {
+		t.Error("TryGo succeeded while the limit is used")
+	}
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !cg.TryGo("b", func() error { return nil }) {
+		t.Error("TryGo failed with the limit available")
+	}
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimitRoundRobinCancel(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetLimit(0)
+	cg.SetLimitPolicy(concgroup.LimitRoundRobin)
+	task := cg.GoHandle("a", func() error { return nil })
+	time.Sleep(10 * time.Millisecond)
+	task.Cancel()
+	<-task.Done()
+	if !errors.Is(task.Err(), concgroup.ErrCanceled) {
+		t.Errorf("got %v, want %v", task.Err(), concgroup.ErrCanceled)
+	}
+	var called bool
+	cg.GoCtx("b", func(context.Context) error {
+		called = true
+		return nil
+	})
+	cg.SetLimit(1)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("the function was not called after the limit was raised")
+	}
+}
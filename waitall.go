@@ -0,0 +1,41 @@
+package concgroup
+
+import (
+	"sync"
+)
+
+// WaitAll blocks until all function calls have returned like Wait, and returns the errors returned by the functions
+// per key since the last WaitAll or Reset along with the error of Wait, so that batch callers can report the outcome
+// of every key.
+// A function with multiple keys has its error under each of them. Canceled functions, and the functions whose errors
+// are handled by Daemon, are not included. The map is nil if no function has returned an error.
+func (g *Group) WaitAll() (map[string][]error, error) {
+	err := g.Wait()
+	return g.keyErrs.take(), err
+}
+
+// keyErrors records the errors of functions per key for WaitAll.
+type keyErrors struct {
+	mu   sync.Mutex
+	errs map[string][]error
+}
+
+func (k *keyErrors) add(r TaskResult) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.errs == nil {
+		k.errs = map[string][]error{}
+	}
+	for _, key := range r.Keys {
+		k.errs[key] = append(k.errs[key], r.Err)
+	}
+}
+
+// take returns the errors and clears them.
+func (k *keyErrors) take() map[string][]error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	errs := k.errs
+	k.errs = nil
+	return errs
+}
@@ -0,0 +1,36 @@
+package concgroup_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestWaitAll(t *testing.T) {
+	t.Parallel()
+	cg := new(concgroup.Group)
+	cg.SetContinueOnError(true)
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	cg.Go("tenant-a", func() error { return errA })
+	cg.Go("tenant-a", func() error { return nil })
+	cg.GoMulti([]string{"tenant-a", "tenant-b"}, func() error { return errB })
+	cg.Go("tenant-c", func() error { return nil })
+	errs, err := cg.WaitAll()
+	if !errors.Is(err, errA) {
+		t.Errorf("got %v, want %v", err, errA)
+	}
+	if got, want := fmt.Sprint(errs), fmt.Sprint(map[string][]error{
+		"tenant-a": {errA, errB},
+		"tenant-b": {errB},
+	}); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	cg.Go("tenant-c", func() error { return nil })
+	errs, err = cg.WaitAll()
+	if err != nil || errs != nil {
+		t.Errorf("got %v and %v, want none", errs, err)
+	}
+}
@@ -0,0 +1,26 @@
+package concgroup
+
+// WaitKey blocks until all the functions submitted with any of keys have returned or been canceled,
+// while the functions with other keys keep running, such as for a request handler to wait only for the keys it touched.
+// Functions submitted with the keys while it waits are waited for as well.
+// It does not wait for functions not submitted yet, such as the ones of GoAfter waiting for their dependencies,
+// and it waits for the functions of the subgroups sharing the key locks too. Unlike Wait, it does not return their errors.
+func (g *Group) WaitKey(keys ...string) {
+	g.init()
+	lt := g.locks
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	for lt.referred(keys) {
+		lt.keyFreed.Wait()
+	}
+}
+
+// referred reports whether any task refers to any of keys.
+func (lt *lockTable) referred(keys []string) bool {
+	for _, key := range keys {
+		if lt.refs(key) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package concgroup_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/concgroup"
+)
+
+func TestWaitKey(t *testing.T) {
+	cg := &concgroup.Group{}
+	block := make(chan struct{})
+	var done atomic.Int32
+	for i := 0; i < 3; i++ {
+		cg.Go("a", func() error {
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+			return nil
+		})
+	}
+	cg.GoMulti([]string{"b", "c"}, func() error {
+		time.Sleep(time.Millisecond)
+		done.Add(1)
+		return nil
+	})
+	cg.Go("other", func() error {
+		<-block
+		return nil
+	})
+	cg.WaitKey("a", "c")
+	if got := done.Load(); got != 4 {
+		t.Errorf("got %d returned, want %d", got, 4)
+	}
+	cg.WaitKey("unknown")
+	close(block)
+	if err := cg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}